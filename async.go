@@ -0,0 +1,232 @@
+package log
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// LevelWriter 可以感知日志等级的输出，[Log] 会优先调用 WriteLevel
+type LevelWriter interface {
+	io.Writer
+	WriteLevel(level int32, p []byte) (n int, err error)
+}
+
+// FieldWriter 需要结构化字段的输出，例如 [SyslogWriter]，[Log] 会优先调用 WriteFields
+// fields在调用返回后仍然有效，但不能修改
+type FieldWriter interface {
+	io.Writer
+	WriteFields(level int32, p []byte, fields []Field) (n int, err error)
+}
+
+// writeEntry 将日志写入w，按w实现的接口附带日志等级和结构化字段
+func writeEntry(w io.Writer, level int32, p []byte, fields []Field) error {
+	var err error
+	switch v := w.(type) {
+	case FieldWriter:
+		_, err = v.WriteFields(level, p, fields)
+	case LevelWriter:
+		_, err = v.WriteLevel(level, p)
+	default:
+		_, err = w.Write(p)
+	}
+	return err
+}
+
+// OverflowPolicy 异步队列满时的处理策略
+type OverflowPolicy int
+
+const (
+	OverflowBlock      OverflowPolicy = iota // 阻塞直到队列有空位
+	OverflowDropNewest                       // 丢弃当前日志
+	OverflowDropOldest                       // 丢弃队列中最早的日志
+	OverflowDropBelow                        // 丢弃低于指定等级的日志，其余阻塞
+)
+
+type asyncEntry struct {
+	level  int32
+	p      []byte
+	fields []Field
+}
+
+// AsyncWriter 异步输出，日志先进入有界队列，由后台协程写入底层输出
+// 避免慢速的磁盘或网络阻塞业务协程
+type AsyncWriter struct {
+	w        io.Writer
+	policy   OverflowPolicy
+	minLevel int32
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	queue    []asyncEntry // 环形队列
+	head     int
+	size     int
+	busy     bool // 后台协程正在写入
+	closed   bool
+	err      error // 底层输出最近一次的错误
+	done     chan struct{}
+
+	dropped atomic.Uint64
+}
+
+// AsyncOption 异步输出的配置
+type AsyncOption func(a *AsyncWriter)
+
+// WithOverflowPolicy 设置队列满时的处理策略，默认阻塞
+func WithOverflowPolicy(policy OverflowPolicy) AsyncOption {
+	return func(a *AsyncWriter) {
+		a.policy = policy
+	}
+}
+
+// WithDropBelow 队列满时丢弃低于level的日志，等级不低于level的日志阻塞等待
+func WithDropBelow(level Level) AsyncOption {
+	return func(a *AsyncWriter) {
+		a.policy = OverflowDropBelow
+		a.minLevel = int32(level)
+	}
+}
+
+// NewAsyncWriter 创建容量为capacity的异步输出
+func NewAsyncWriter(w io.Writer, capacity int, options ...AsyncOption) *AsyncWriter {
+	if capacity <= 0 {
+		capacity = 1024
+	}
+	a := &AsyncWriter{
+		w:     w,
+		queue: make([]asyncEntry, capacity),
+		done:  make(chan struct{}),
+	}
+	a.notEmpty = sync.NewCond(&a.mu)
+	a.notFull = sync.NewCond(&a.mu)
+	for _, op := range options {
+		op(a)
+	}
+	go a.run()
+	return a
+}
+
+// Write implements io.Writer.
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	return a.WriteLevel(debugLevel, p)
+}
+
+// WriteLevel implements LevelWriter. p会被复制，调用方可以立即复用p
+func (a *AsyncWriter) WriteLevel(level int32, p []byte) (int, error) {
+	return a.WriteFields(level, p, nil)
+}
+
+// WriteFields implements FieldWriter. 日志等级和结构化字段会一起交给底层输出
+func (a *AsyncWriter) WriteFields(level int32, p []byte, fields []Field) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for !a.closed && a.size == len(a.queue) {
+		switch {
+		case a.policy == OverflowDropNewest,
+			a.policy == OverflowDropBelow && level < a.minLevel:
+			a.dropped.Add(1)
+			return len(p), nil
+		case a.policy == OverflowDropOldest:
+			a.queue[a.head] = asyncEntry{}
+			a.head = (a.head + 1) % len(a.queue)
+			a.size--
+			a.dropped.Add(1)
+		default:
+			a.notFull.Wait()
+		}
+	}
+	if a.closed {
+		return 0, os.ErrClosed
+	}
+
+	a.queue[(a.head+a.size)%len(a.queue)] = asyncEntry{level: level, p: append([]byte(nil), p...), fields: fields}
+	a.size++
+	a.notEmpty.Signal()
+	return len(p), nil
+}
+
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for {
+		for a.size == 0 && !a.closed {
+			a.notEmpty.Wait()
+		}
+		if a.size == 0 && a.closed {
+			return
+		}
+		e := a.queue[a.head]
+		a.queue[a.head] = asyncEntry{}
+		a.head = (a.head + 1) % len(a.queue)
+		a.size--
+		a.busy = true
+		a.notFull.Signal()
+
+		a.mu.Unlock()
+		err := writeEntry(a.w, e.level, e.p, e.fields)
+		a.mu.Lock()
+
+		a.busy = false
+		if err != nil {
+			a.err = err
+		}
+		// 唤醒等待Flush的协程
+		a.notFull.Broadcast()
+	}
+}
+
+// ConcurrentSafe implements ConcurrentWriter.
+func (a *AsyncWriter) ConcurrentSafe() {}
+
+// Dropped 返回因队列满而丢弃的日志条数
+func (a *AsyncWriter) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+// Flush 等待队列中的日志全部写入底层输出，并返回期间底层输出的错误
+func (a *AsyncWriter) Flush() error {
+	a.mu.Lock()
+	for a.size > 0 || a.busy {
+		a.notFull.Wait()
+	}
+	err := a.err
+	a.err = nil
+	a.mu.Unlock()
+
+	if f, ok := a.w.(flusher); ok {
+		if ferr := f.Flush(); ferr != nil && err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+// Close 写完队列中剩余的日志后关闭底层输出，标准输出和标准错误不会被关闭
+func (a *AsyncWriter) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	a.notEmpty.Broadcast()
+	a.notFull.Broadcast()
+	a.mu.Unlock()
+
+	<-a.done
+	err := a.Flush()
+	if a.w == io.Writer(os.Stdout) || a.w == io.Writer(os.Stderr) {
+		return err
+	}
+	if c, ok := a.w.(io.Closer); ok {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,112 @@
+package log
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// blockingWriter 在unblock关闭前阻塞写入
+type blockingWriter struct {
+	mu      sync.Mutex
+	b       strings.Builder
+	started chan struct{}
+	unblock chan struct{}
+	once    sync.Once
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	<-w.unblock
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.b.Write(p)
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{started: make(chan struct{}), unblock: make(chan struct{})}
+}
+
+func TestAsyncWriterDropOldest(t *testing.T) {
+	bw := newBlockingWriter()
+	a := NewAsyncWriter(bw, 2, WithOverflowPolicy(OverflowDropOldest))
+
+	_, _ = a.Write([]byte("0\n"))
+	<-bw.started // "0" is in flight, the queue is empty again
+	for _, s := range []string{"1\n", "2\n", "3\n", "4\n"} {
+		_, _ = a.Write([]byte(s))
+	}
+	close(bw.unblock)
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := bw.b.String(); got != "0\n3\n4\n" {
+		t.Errorf("got %q", got)
+	}
+	if a.Dropped() != 2 {
+		t.Errorf("dropped %d, want 2", a.Dropped())
+	}
+}
+
+func TestAsyncWriterDropBelow(t *testing.T) {
+	bw := newBlockingWriter()
+	a := NewAsyncWriter(bw, 1, WithDropBelow(LevelWarn))
+
+	_, _ = a.Write([]byte("0\n"))
+	<-bw.started
+	_, _ = a.WriteLevel(errorLevel, []byte("1\n"))
+	_, _ = a.WriteLevel(infoLevel, []byte("dropped\n"))
+	close(bw.unblock)
+	_, _ = a.WriteLevel(errorLevel, []byte("2\n")) // blocks until there is room
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := bw.b.String(); got != "0\n1\n2\n" {
+		t.Errorf("got %q", got)
+	}
+	if a.Dropped() != 1 {
+		t.Errorf("dropped %d, want 1", a.Dropped())
+	}
+}
+
+func TestAsyncWriterLogClose(t *testing.T) {
+	w := new(closeCounter)
+	l := New(NewAsyncWriter(w, 16), "", 0)
+	l.SetFormatter(&DefaultFormat{})
+	for i := 0; i < 100; i++ {
+		l.Info("line %d", i)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(w.String(), "\n"); n != 100 {
+		t.Errorf("got %d lines, want 100", n)
+	}
+	if w.closed != 1 {
+		t.Errorf("underlying writer closed %d times", w.closed)
+	}
+}
+
+// fieldRecorder 记录收到的等级和字段
+type fieldRecorder struct {
+	levels []int32
+	fields [][]Field
+}
+
+func (r *fieldRecorder) Write(p []byte) (int, error) { return len(p), nil }
+
+func (r *fieldRecorder) WriteFields(level int32, p []byte, fields []Field) (int, error) {
+	r.levels = append(r.levels, level)
+	r.fields = append(r.fields, fields)
+	return len(p), nil
+}
+
+func TestAsyncWriterForwardsFields(t *testing.T) {
+	rec := new(fieldRecorder)
+	l := New(NewAsyncWriter(rec, 8), "", 0)
+	l.Warnw("slow", "ms", 120)
+	l.Close()
+	if len(rec.levels) != 1 || rec.levels[0] != warnLevel || len(rec.fields[0]) != 1 || rec.fields[0][0] != F("ms", 120) {
+		t.Errorf("got levels %v fields %v", rec.levels, rec.fields)
+	}
+}
@@ -0,0 +1,57 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/nbcx/log"
+)
+
+// 无论经由包级函数、With还是Context，调用位置都应该是用户代码
+func TestPackageLevelCaller(t *testing.T) {
+	var buf bytes.Buffer
+	std := log.Default()
+	std.SetWriter(&buf)
+	std.SetFormatter(log.NewJSON())
+	defer func() {
+		std.SetWriter(os.Stdout)
+		std.SetFormatter(log.NewConsole())
+	}()
+
+	_, _, line, _ := runtime.Caller(0)
+	log.Info("info")
+	log.Infow("infow", "k", 1)
+	log.InfoContext(context.Background(), "context")
+	std.Info("method")
+
+	dec := json.NewDecoder(&buf)
+	for i := 1; i <= 4; i++ {
+		var m map[string]any
+		if err := dec.Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		want := "caller_test.go:" + strconv.Itoa(line+i)
+		if m["caller"] != want || m["func"] != "github.com/nbcx/log_test.TestPackageLevelCaller" {
+			t.Errorf("%s: caller %v func %v, want %s", m["msg"], m["caller"], m["func"], want)
+		}
+	}
+}
+
+func TestTextFormatterCaller(t *testing.T) {
+	var buf bytes.Buffer
+	f, _ := log.NewFormatter("text")
+	l := log.New(&buf, "", log.Lshortfile)
+	l.SetFormatter(f)
+
+	_, _, line, _ := runtime.Caller(0)
+	l.Info("direct")
+	if want := "[caller_test.go:" + strconv.Itoa(line+1) + "]"; !strings.Contains(buf.String(), want) {
+		t.Errorf("got %q, want %s", buf.String(), want)
+	}
+}
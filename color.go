@@ -2,11 +2,26 @@ package log
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"runtime"
 	"sync"
 	"time"
+
+	"github.com/shiena/ansicolor"
 )
 
+// colorWriter 在Windows控制台上将ANSI颜色转义序列转换为控制台调用，其它系统上直接写入
+// 保留底层的文件，用于判断输出是否为终端
+type colorWriter struct {
+	io.Writer
+	file *os.File
+}
+
+func newColorWriter(f *os.File) io.Writer {
+	return &colorWriter{Writer: ansicolor.NewAnsiColorWriter(f), file: f}
+}
+
 // brush is a color join function
 type brush func(string) string
 
@@ -120,30 +135,41 @@ func ResetColor() string {
 }
 
 // consoleWriter implements LoggerInterface and writes messages to terminal.
-type consoleWriter struct{}
+type consoleWriter struct {
+	noColor bool // 不为等级着色，见 [FromEnv]
+}
 
 // NewConsole creates ConsoleWriter returning as LoggerInterface.
 func NewConsole() *consoleWriter {
 	return &consoleWriter{}
 }
 
-func (c *consoleWriter) Format(lm *Option, level int32, format string, args ...interface{}) []byte {
-	buf := getBuffer()
-	defer putBuffer(buf)
-
+func (c *consoleWriter) Format(lm *Option, buf *[]byte, level int32, format string, args ...interface{}) {
 	// time
 	lm.Time(buf, time.Now())
 	// level
-	*buf = append(*buf, colors[level](levelConsolePrefix[level])+" "...)
+	if c.noColor {
+		*buf = append(*buf, levelConsolePrefix[level]...)
+		*buf = append(*buf, ' ')
+	} else {
+		*buf = append(*buf, colors[level](levelConsolePrefix[level])+" "...)
+	}
+	// prefix
+	if prefix := lm.Prefix(); prefix != "" {
+		*buf = append(*buf, prefix...)
+		if lm.named {
+			*buf = append(*buf, ' ')
+		}
+	}
 	// msg
 	msg := format
 	if len(args) > 0 {
 		msg = fmt.Sprintf(format, args...)
 	}
 	*buf = append(*buf, msg...)
+	appendTextFields(buf, lm.Fields())
 
 	if len(*buf) == 0 || (*buf)[len(*buf)-1] != '\n' {
 		*buf = append(*buf, '\n')
 	}
-	return *buf
 }
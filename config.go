@@ -0,0 +1,320 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config 日志实例的声明式配置，可以从JSON或YAML文件解码，通过 [Config.Build] 创建日志实例
+//
+//	level: info
+//	formatter: json
+//	flags: date|time|shortfile
+//	outputs:
+//	  - path: stdout
+//	  - path: /var/log/app/error.log
+//	    level: error
+//	    max_size: 104857600
+//	    max_backups: 7
+//	    compress: true
+type Config struct {
+	Level     string         `json:"level" yaml:"level"`               // 日志等级，格式见 [ParseLevel]，默认为debug
+	Formatter string         `json:"formatter" yaml:"formatter"`       // 已注册的格式器名称，见 [Formatters]，默认为console
+	Flags     string         `json:"flags" yaml:"flags"`               // 格式见 [ParseFlags]，默认为 date|time|shortfile
+	Prefix    string         `json:"prefix" yaml:"prefix"`             // 日志前缀，原样输出
+	CallDepth int            `json:"caller_depth" yaml:"caller_depth"` // 调用位置的栈深度，默认为4，封装Log时需要增加
+	Outputs   []OutputConfig `json:"outputs" yaml:"outputs"`           // 输出，为空时输出到标准输出
+	Vmodule   string         `json:"vmodule" yaml:"vmodule"`           // 按模块覆盖等级，格式见 [Log.SetModuleLevel]
+}
+
+// OutputConfig 一个输出的配置
+// 没有指定等级的输出接收所有日志，指定等级的输出只接收不低于该等级的日志
+type OutputConfig struct {
+	Path       string `json:"path" yaml:"path"`               // stdout、stderr或文件路径
+	Level      string `json:"level" yaml:"level"`             // 该输出的最低等级
+	MaxSize    int64  `json:"max_size" yaml:"max_size"`       // 单个文件的最大字节数，见 [WithMaxSize]
+	Rotate     string `json:"rotate" yaml:"rotate"`           // 按时间切割，never、hourly或daily
+	MaxBackups int    `json:"max_backups" yaml:"max_backups"` // 保留的备份数量
+	MaxAge     string `json:"max_age" yaml:"max_age"`         // 备份的最长保留时间，例如 "168h"
+	Compress   bool   `json:"compress" yaml:"compress"`       // 是否压缩备份
+}
+
+// LoadConfig 读取配置文件，根据扩展名按JSON（.json）或YAML（.yaml、.yml）解码
+// 未知的字段会返回错误，避免拼写错误的配置被忽略
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeConfig(path, data)
+}
+
+// decodeConfig 根据path的扩展名解码配置
+func decodeConfig(path string, data []byte) (*Config, error) {
+	c := new(Config)
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(c)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err = dec.Decode(c); err == io.EOF {
+			err = nil
+		}
+	default:
+		return nil, fmt.Errorf("log: unsupported config file extension %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("log: decode %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// flagNames [ParseFlags] 支持的名称
+var flagNames = map[string]int{
+	"date":         Ldate,
+	"time":         Ltime,
+	"microseconds": Lmicroseconds,
+	"longfile":     Llongfile,
+	"shortfile":    Lshortfile,
+	"utc":          LUTC,
+	"msgprefix":    Lmsgprefix,
+	"stdflags":     LstdFlags,
+	"none":         0,
+}
+
+// ParseFlags 解析以 | 或逗号分隔的flag名称，例如 "date|time|shortfile"
+// 支持 date、time、microseconds、longfile、shortfile、utc、msgprefix、stdflags 和 none，不区分大小写
+func ParseFlags(s string) (int, error) {
+	flag := 0
+	for _, name := range strings.FieldsFunc(s, func(r rune) bool { return r == '|' || r == ',' }) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		v, ok := flagNames[name]
+		if !ok {
+			return 0, fmt.Errorf("log: unknown flag %q", name)
+		}
+		flag |= v
+	}
+	return flag, nil
+}
+
+// Build 校验配置并创建日志实例，所有错误会一起返回
+func (c *Config) Build() (*Log, error) {
+	st, err := c.prepare(nil)
+	if err != nil {
+		return nil, err
+	}
+	l := newLog(&Option{
+		CallDepth:    st.callDepth,
+		ShowFuncName: true,
+	})
+	st.apply(l)
+	return l, nil
+}
+
+// configState 校验通过的配置，输出已经打开，尚未作用于日志实例
+type configState struct {
+	level     Level
+	flag      int
+	formatter Formatter
+	prefix    string
+	callDepth int
+	vmodule   string
+	outs      builtOutputs
+}
+
+// prepare 校验配置并打开输出，reuse中路径相同的文件直接复用而不重新打开
+// 失败时关闭新打开的文件
+func (c *Config) prepare(reuse map[string]*RotatingFile) (*configState, error) {
+	st := &configState{
+		level:     LevelDebug,
+		flag:      LstdFlags | Ltime | Lshortfile,
+		formatter: NewConsole(),
+		prefix:    c.Prefix,
+		callDepth: 4,
+		vmodule:   c.Vmodule,
+	}
+	var errs []error
+	if c.Level != "" {
+		lv, err := ParseLevel(c.Level)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("level: %w", err))
+		}
+		st.level = lv
+	}
+	if c.Formatter != "" {
+		f, err := NewFormatter(c.Formatter)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("formatter: %w", err))
+		}
+		st.formatter = f
+	}
+	if c.Flags != "" {
+		flag, err := ParseFlags(c.Flags)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("flags: %w", err))
+		}
+		st.flag = flag
+	}
+	switch {
+	case c.CallDepth < 0:
+		errs = append(errs, fmt.Errorf("caller_depth: must not be negative, got %d", c.CallDepth))
+	case c.CallDepth > 0:
+		st.callDepth = c.CallDepth
+	}
+	if _, err := parseModuleSpec(c.Vmodule); err != nil {
+		errs = append(errs, fmt.Errorf("vmodule: %w", err))
+	}
+	outs, err := c.buildOutputs(reuse)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		for path, f := range outs.files {
+			if reuse[path] != f {
+				_ = f.Close()
+			}
+		}
+		return nil, errors.Join(errs...)
+	}
+	st.outs = outs
+	return st, nil
+}
+
+// apply 将配置作用于l，不包括CallDepth
+// 所有配置整体替换，每条日志要么完全使用原来的配置，要么完全使用新的配置；返回后原来的输出不再有写入
+func (st *configState) apply(l *Log) {
+	l.reloadMu.Lock()
+	defer l.reloadMu.Unlock()
+	l.SetFlag(st.flag)
+	l.SetLogPrefix(st.prefix)
+	_ = l.SetModuleLevel(st.vmodule)
+	l.SetFormatter(st.formatter)
+	for f, options := range st.outs.reused {
+		f.reconfigure(options)
+	}
+	l.update(func(o *outputs) {
+		o.writer = st.outs.writer
+		o.extra = st.outs.extra
+	})
+	l.SetLevel(st.level)
+}
+
+// builtOutputs 根据配置打开的输出
+type builtOutputs struct {
+	writer io.Writer
+	extra  [fatalLevel + 1][]io.Writer
+	files  map[string]*RotatingFile         // 用到的文件，同一路径只打开一次
+	reused map[*RotatingFile][]RotateOption // 复用的文件及其新的切割配置，应用配置时生效
+}
+
+// buildOutputs 打开所有输出，第一个没有指定等级的输出作为主输出，其余的按等级作为额外输出
+func (c *Config) buildOutputs(reuse map[string]*RotatingFile) (builtOutputs, error) {
+	outs := builtOutputs{files: make(map[string]*RotatingFile), reused: make(map[*RotatingFile][]RotateOption)}
+	if len(c.Outputs) == 0 {
+		outs.writer = os.Stdout
+		return outs, nil
+	}
+	var errs []error
+	for i, oc := range c.Outputs {
+		w, lv, err := oc.open(reuse, &outs)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("outputs[%d]: %w", i, err))
+			continue
+		}
+		if oc.Level == "" && outs.writer == nil {
+			outs.writer = w
+			continue
+		}
+		for ; lv <= LevelFatal; lv++ {
+			outs.extra[lv] = append(outs.extra[lv], w)
+		}
+	}
+	if outs.writer == nil {
+		// 所有输出都指定了等级，其余的日志丢弃
+		outs.writer = io.Discard
+	}
+	return outs, errors.Join(errs...)
+}
+
+// open 打开输出，返回它接收的最低等级
+// 同一配置中的文件只打开一次，切割配置以第一次出现时为准；
+// 文件在reuse中时继续使用，新的切割配置记录在outs.reused中，应用配置时生效
+func (oc OutputConfig) open(reuse map[string]*RotatingFile, outs *builtOutputs) (io.Writer, Level, error) {
+	lv := LevelDebug
+	if oc.Level != "" {
+		var err error
+		if lv, err = ParseLevel(oc.Level); err != nil {
+			return nil, 0, err
+		}
+	}
+	switch oc.Path {
+	case "":
+		return nil, 0, errors.New("log: output path is empty")
+	case "stdout":
+		return os.Stdout, lv, nil
+	case "stderr":
+		return os.Stderr, lv, nil
+	}
+
+	var options []RotateOption
+	if oc.MaxSize < 0 {
+		return nil, 0, fmt.Errorf("log: max_size must not be negative, got %d", oc.MaxSize)
+	}
+	if oc.MaxSize > 0 {
+		options = append(options, WithMaxSize(oc.MaxSize))
+	}
+	switch strings.ToLower(oc.Rotate) {
+	case "", "never":
+	case "hourly":
+		options = append(options, WithRotateInterval(RotateHourly))
+	case "daily":
+		options = append(options, WithRotateInterval(RotateDaily))
+	default:
+		return nil, 0, fmt.Errorf("log: unknown rotate interval %q", oc.Rotate)
+	}
+	if oc.MaxBackups < 0 {
+		return nil, 0, fmt.Errorf("log: max_backups must not be negative, got %d", oc.MaxBackups)
+	}
+	if oc.MaxBackups > 0 {
+		options = append(options, WithMaxBackups(oc.MaxBackups))
+	}
+	if oc.MaxAge != "" {
+		age, err := time.ParseDuration(oc.MaxAge)
+		if err != nil || age < 0 {
+			return nil, 0, fmt.Errorf("log: invalid max_age %q", oc.MaxAge)
+		}
+		options = append(options, WithMaxAge(age))
+	}
+	if oc.Compress {
+		options = append(options, WithCompress())
+	}
+
+	path := filepath.Clean(oc.Path)
+	if f, ok := outs.files[path]; ok {
+		return f, lv, nil
+	}
+	if f, ok := reuse[path]; ok {
+		outs.files[path] = f
+		outs.reused[f] = options
+		return f, lv, nil
+	}
+	f, err := NewRotatingFile(path, options...)
+	if err != nil {
+		return nil, 0, err
+	}
+	outs.files[path] = f
+	return f, lv, nil
+}
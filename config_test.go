@@ -0,0 +1,112 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFlags(t *testing.T) {
+	for s, want := range map[string]int{
+		"date|time|shortfile": Ldate | Ltime | Lshortfile,
+		"stdflags, UTC":       LstdFlags | LUTC,
+		"none":                0,
+		"":                    0,
+	} {
+		if got, err := ParseFlags(s); err != nil || got != want {
+			t.Errorf("ParseFlags(%q) = %d, %v; want %d", s, got, err, want)
+		}
+	}
+	if _, err := ParseFlags("date|tme"); err == nil {
+		t.Error("ParseFlags accepted unknown flag")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	all := filepath.Join(dir, "all.log")
+	errs := filepath.Join(dir, "error.log")
+	yml := filepath.Join(dir, "log.yaml")
+	os.WriteFile(yml, []byte(`
+level: info
+formatter: logfmt
+flags: none
+prefix: app
+outputs:
+  - path: `+all+`
+  - path: `+errs+`
+    level: error
+    max_size: 1024
+    max_backups: 3
+`), 0o644)
+
+	c, err := LoadConfig(yml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := c.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Debug("hidden")
+	l.Info("started")
+	l.Error("failed")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := os.ReadFile(all)
+	if got := string(b); got != "level=info logger=app msg=started\nlevel=error logger=app msg=failed\n" {
+		t.Errorf("all.log = %q", got)
+	}
+	b, _ = os.ReadFile(errs)
+	if got := string(b); got != "level=error logger=app msg=failed\n" {
+		t.Errorf("error.log = %q", got)
+	}
+}
+
+func TestLoadConfigJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.json")
+	os.WriteFile(path, []byte(`{"level":"warning","formatter":"json","caller_depth":5}`), 0o644)
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := c.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Level() != LevelWarn || l.option().CallDepth != 5 {
+		t.Errorf("level %v, call depth %d", l.Level(), l.option().CallDepth)
+	}
+
+	os.WriteFile(path, []byte(`{"levle":"info"}`), 0o644)
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("LoadConfig accepted unknown field")
+	}
+}
+
+func TestConfigBuildErrors(t *testing.T) {
+	c := &Config{
+		Level:     "eror",
+		Formatter: "xml",
+		Flags:     "date|tme",
+		CallDepth: -1,
+		Outputs: []OutputConfig{
+			{Path: filepath.Join(t.TempDir(), "a.log")},
+			{Path: "stderr", Level: "fatl"},
+			{Path: ""},
+			{Path: "b.log", Rotate: "weekly"},
+		},
+	}
+	_, err := c.Build()
+	if err == nil {
+		t.Fatal("Build succeeded")
+	}
+	for _, want := range []string{`level: log: unknown level "eror"`, `formatter:`, `unknown flag "tme"`, "caller_depth", `outputs[1]:`, `outputs[2]:`, `outputs[3]:`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
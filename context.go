@@ -0,0 +1,113 @@
+package log
+
+import (
+	"context"
+	"sync"
+)
+
+// ContextExtractor 从context中提取需要附加到日志的字段，例如trace id
+type ContextExtractor func(ctx context.Context) []Field
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []ContextExtractor
+)
+
+// RegisterContextExtractor 注册一个context字段提取器
+// 通过 *Context 系列方法输出日志时，所有提取器返回的字段会按注册顺序附加到日志上
+func RegisterContextExtractor(fn ContextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors = append(extractors, fn)
+}
+
+// contextFields 返回所有提取器从ctx中提取的字段
+func contextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+	var fields []Field
+	for _, fn := range extractors {
+		fields = append(fields, fn(ctx)...)
+	}
+	return fields
+}
+
+type contextKey struct{}
+
+// NewContext 返回一个携带l的context
+func NewContext(ctx context.Context, l *Log) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext 返回ctx中携带的日志实例，没有时返回默认日志实例
+func FromContext(ctx context.Context) *Log {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(*Log); ok {
+			return l
+		}
+	}
+	return std
+}
+
+// DebugContext 输出调试日志，并附加从ctx中提取的字段
+func (l *Log) DebugContext(ctx context.Context, format any, a ...interface{}) {
+	l.print(ctx, debugLevel, format, a...)
+}
+
+// InfoContext 输出信息日志，并附加从ctx中提取的字段
+func (l *Log) InfoContext(ctx context.Context, format any, a ...interface{}) {
+	l.print(ctx, infoLevel, format, a...)
+}
+
+// WarnContext 输出警告日志，并附加从ctx中提取的字段
+func (l *Log) WarnContext(ctx context.Context, format any, a ...interface{}) {
+	l.print(ctx, warnLevel, format, a...)
+}
+
+// ErrorContext 输出错误日志，并附加从ctx中提取的字段
+func (l *Log) ErrorContext(ctx context.Context, format any, a ...interface{}) {
+	l.print(ctx, errorLevel, format, a...)
+}
+
+// PanicContext 输出日志后panic，并附加从ctx中提取的字段
+func (l *Log) PanicContext(ctx context.Context, format any, a ...interface{}) {
+	l.print(ctx, panicLevel, format, a...)
+}
+
+// FatalContext 输出日志后退出程序，并附加从ctx中提取的字段
+func (l *Log) FatalContext(ctx context.Context, format any, a ...interface{}) {
+	l.print(ctx, fatalLevel, format, a...)
+}
+
+// DebugContext DebugContext
+func DebugContext(ctx context.Context, format any, a ...interface{}) {
+	std.print(ctx, debugLevel, format, a...)
+}
+
+// InfoContext InfoContext
+func InfoContext(ctx context.Context, format any, a ...interface{}) {
+	std.print(ctx, infoLevel, format, a...)
+}
+
+// WarnContext WarnContext
+func WarnContext(ctx context.Context, format any, a ...interface{}) {
+	std.print(ctx, warnLevel, format, a...)
+}
+
+// ErrorContext ErrorContext
+func ErrorContext(ctx context.Context, format any, a ...interface{}) {
+	std.print(ctx, errorLevel, format, a...)
+}
+
+// PanicContext PanicContext
+func PanicContext(ctx context.Context, format any, a ...interface{}) {
+	std.print(ctx, panicLevel, format, a...)
+}
+
+// FatalContext FatalContext
+func FatalContext(ctx context.Context, format any, a ...interface{}) {
+	std.print(ctx, fatalLevel, format, a...)
+}
@@ -0,0 +1,70 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+)
+
+type traceKey struct{}
+
+// extracted 统计提取器被调用的次数
+var extracted atomic.Int64
+
+func init() {
+	RegisterContextExtractor(func(ctx context.Context) []Field {
+		extracted.Add(1)
+		if id, ok := ctx.Value(traceKey{}).(string); ok {
+			return []Field{F("trace", id)}
+		}
+		return nil
+	})
+}
+
+func TestContext(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "", 0)
+	l.SetFormatter(&DefaultFormat{})
+
+	ctx := NewContext(context.WithValue(context.Background(), traceKey{}, "t-1"), l)
+	if FromContext(ctx) != l {
+		t.Fatal("FromContext should return the logger stored by NewContext")
+	}
+	if FromContext(context.Background()) != std {
+		t.Fatal("FromContext should fall back to the default logger")
+	}
+
+	FromContext(ctx).With("user", 1).InfoContext(ctx, "hello %s", "world")
+	l.WarnContext(context.Background(), "no trace")
+	slog.New(NewSlogHandler(l)).ErrorContext(ctx, "from slog")
+
+	want := "[info ] hello world user=1 trace=t-1\n" +
+		"[warn ] no trace\n" +
+		"[error] from slog trace=t-1\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestContextBelowLevel(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "", 0)
+	l.SetLevel(LevelWarn)
+	ctx := context.WithValue(context.Background(), traceKey{}, "t-1")
+
+	n := extracted.Load()
+	l.DebugContext(ctx, "hidden")
+	l.InfoContext(ctx, "hidden")
+	if got := extracted.Load() - n; got != 0 {
+		t.Errorf("extractors ran %d times for disabled levels", got)
+	}
+	l.WarnContext(ctx, "shown")
+	if got := extracted.Load() - n; got != 1 {
+		t.Errorf("extractors ran %d times, want 1", got)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { l.InfoContext(ctx, "hidden") }); allocs != 0 {
+		t.Errorf("disabled InfoContext allocates %v times", allocs)
+	}
+}
@@ -0,0 +1,126 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// dedup 合并连续重复的日志，每个输出单独记录上一条日志
+// 状态由core.mu保护
+type dedup struct {
+	window time.Duration
+	last   map[io.Writer]*dupState
+	timer  *time.Timer
+}
+
+// dupKey 用于判断两条日志是否相同，前缀不同（例如不同的Named实例）的日志不会被合并
+type dupKey struct {
+	msg    string // 格式化后的内容和字段
+	prefix string
+	named  bool
+}
+
+type dupState struct {
+	level int32
+	key   dupKey
+	count uint64 // 被合并的重复条数
+}
+
+// SetDedup 开启或关闭重复日志合并
+// 开启后，同一输出上连续出现的等级和内容都相同的日志只输出第一条，
+// 在下一条不同的日志之前、距第一次合并window之后或者Close时输出 "last message repeated N times"
+// window为0时关闭
+func (l *Log) SetDedup(window time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if old := l.dedup.Load(); old != nil {
+		old.flush(l.core)
+	}
+	if window <= 0 {
+		l.dedup.Store(nil)
+		return
+	}
+	l.dedup.Store(&dedup{window: window, last: make(map[io.Writer]*dupState)})
+}
+
+// dedupKey 用于判断两条日志是否相同，未开启合并时返回零值
+func (c *core) dedupKey(opt *Option, msg string, a []interface{}) dupKey {
+	if c.dedup.Load() == nil {
+		return dupKey{}
+	}
+	if len(a) > 0 {
+		msg = fmt.Sprintf(msg, a...)
+	}
+	for _, f := range opt.Fields() {
+		msg += "\x00" + f.Key + "=" + fmt.Sprint(f.Value)
+	}
+	return dupKey{msg: msg, prefix: opt.Prefix(), named: opt.named}
+}
+
+// pass 判断日志是否需要写入w，需要时先输出之前被合并的条数
+func (d *dedup) pass(c *core, w io.Writer, level int32, key dupKey) bool {
+	if !reflect.TypeOf(w).Comparable() {
+		return true
+	}
+	st := d.last[w]
+	if st == nil {
+		d.last[w] = &dupState{level: level, key: key}
+		return true
+	}
+	if st.level == level && st.key == key {
+		st.count++
+		if d.timer == nil {
+			d.timer = time.AfterFunc(d.window, func() {
+				c.mu.Lock()
+				defer c.mu.Unlock()
+				if !c.closed.Load() && c.dedup.Load() == d {
+					d.flush(c)
+				}
+			})
+		}
+		return false
+	}
+	d.report(c, w, st)
+	st.level, st.key = level, key
+	return true
+}
+
+// report 向w输出被合并的条数
+func (d *dedup) report(c *core, w io.Writer, st *dupState) {
+	if st.count == 0 {
+		return
+	}
+	f := c.formatter()
+	if f == nil {
+		return
+	}
+	// 使用当前的配置和被合并日志的前缀，不输出调用位置
+	opt := c.shared.Load().clone()
+	opt.Flag &^= Lshortfile | Llongfile
+	opt.ShowFuncName = false
+	opt.fields = nil
+	opt.prefix.Store(nil)
+	if st.key.prefix != "" {
+		opt.SetPrefix(st.key.prefix)
+	}
+	opt.named = st.key.named
+	buf := getBuffer()
+	f.Format(opt, buf, st.level, "last message repeated "+strconv.FormatUint(st.count, 10)+" times")
+	_ = writeEntry(w, st.level, *buf, nil)
+	putBuffer(buf)
+	st.count = 0
+}
+
+// flush 向所有输出汇报被合并的条数
+func (d *dedup) flush(c *core) {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	for w, st := range d.last {
+		d.report(c, w, st)
+	}
+}
@@ -0,0 +1,83 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	var main, extra bytes.Buffer
+	l := New(&main, "", 0)
+	l.SetFormatter(&DefaultFormat{})
+	l.SetLevelWriter("error", &extra)
+	l.SetDedup(time.Hour)
+
+	for i := 0; i < 3; i++ {
+		l.Error("connection refused")
+	}
+	l.Warn("connection refused")
+	l.Error("connection refused")
+	l.With("n", 1).Error("connection refused")
+	l.With("n", 1).Error("connection refused")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "[error] connection refused\n" +
+		"[error] last message repeated 2 times\n" +
+		"[warn ] connection refused\n" +
+		"[error] connection refused\n" +
+		"[error] connection refused n=1\n" +
+		"[error] last message repeated 1 times\n"
+	if got := main.String(); got != want {
+		t.Errorf("main writer: got %q\nwant %q", got, want)
+	}
+	// warn没有写入extra，因此extra上的error是连续的
+	want = "[error] connection refused\n" +
+		"[error] last message repeated 3 times\n" +
+		"[error] connection refused n=1\n" +
+		"[error] last message repeated 1 times\n"
+	if got := extra.String(); got != want {
+		t.Errorf("extra writer: got %q\nwant %q", got, want)
+	}
+}
+
+func TestDedupTimer(t *testing.T) {
+	w := new(lockedWriter)
+	l := New(w, "", 0)
+	l.SetFormatter(&DefaultFormat{})
+	l.SetDedup(10 * time.Millisecond)
+
+	l.Info("flap")
+	l.Info("flap")
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(w.String(), "repeated") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got, want := w.String(), "[info ] flap\n[info ] last message repeated 1 times\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDedupNamed(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "", 0)
+	l.SetFormatter(&DefaultFormat{})
+	l.SetDedup(time.Hour)
+
+	l.Named("db").Error("boom")
+	l.Named("web").Error("boom")
+	l.Named("web").Error("boom")
+	// 汇总使用写入时的配置
+	l.SetFlag(Lmsgprefix)
+	l.Close()
+
+	want := "db [error] boom\n" +
+		"web [error] boom\n" +
+		"[error] web last message repeated 1 times\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
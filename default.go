@@ -1,6 +1,7 @@
 package log
 
 import (
+	"fmt"
 	"io"
 	"sync/atomic"
 	"time"
@@ -25,14 +26,12 @@ type DefaultFormat struct {
 // after the log header if the [Lmsgprefix] flag is provided.
 // The flag argument defines the logging properties.
 func New(out io.Writer, prefix string, flags ...int) *Log {
-	l := new(Log)
-	l.level = debugLevel
-
-	l.opt = &Option{
+	opt := &Option{
 		CallDepth:    4,
 		ShowFuncName: true,
 		Flag:         LstdFlags | Ltime | Lshortfile,
 	}
+	opt.SetPrefix(prefix)
 
 	// todo: wait adjust
 	if len(flags) > 0 {
@@ -40,11 +39,12 @@ func New(out io.Writer, prefix string, flags ...int) *Log {
 		for _, v := range flags {
 			flag = flag | v
 		}
-		l.opt.Flag = flag
+		opt.Flag = flag
 	}
+	l := newLog(opt)
 
-	l.format = NewConsole()
-	l.writer = out
+	l.SetFormatter(NewConsole())
+	l.SetWriter(out)
 	return l
 }
 
@@ -69,22 +69,22 @@ func itoa(buf *[]byte, i int, wid int) {
 }
 
 // Format implements log.Formatter.
-func (l *DefaultFormat) Format(lm *Option, level int32, s string, args ...interface{}) []byte {
+func (l *DefaultFormat) Format(lm *Option, buf *[]byte, level int32, s string, args ...interface{}) {
 
 	now := time.Now() // get this early.
 
-	buf := getBuffer()
-	defer putBuffer(buf)
 	// prefix = lm.PrintLevel(level)
 	// fmt.Println("formatHeader", "now:", now, "prefix:", "flag:", flag)
 	l.formatHeader(lm, buf, now, lm.PrintLevel(level), lm.Flag)
+	if len(args) > 0 {
+		s = fmt.Sprintf(s, args...)
+	}
 	*buf = append(*buf, s...)
+	appendTextFields(buf, lm.Fields())
 	// *buf = appendOutput(*buf)
 	if len(*buf) == 0 || (*buf)[len(*buf)-1] != '\n' {
 		*buf = append(*buf, '\n')
 	}
-
-	return *buf
 }
 
 // formatHeader writes log header to buf in following order:
@@ -93,14 +93,26 @@ func (l *DefaultFormat) Format(lm *Option, level int32, s string, args ...interf
 //   - file and line number (if corresponding flags are provided),
 //   - l.prefix (if it's not blank and Lmsgprefix is set).
 func (l *DefaultFormat) formatHeader(lm *Option, buf *[]byte, t time.Time, level string, flag int) {
+	prefix := l.Prefix()
+	if prefix == "" {
+		// 与标准库一致，前缀原样输出；Named的名称与其它内容之间以空格分隔
+		prefix = lm.Prefix()
+		if lm.named {
+			if flag&Lmsgprefix != 0 {
+				prefix = " " + prefix
+			} else {
+				prefix += " "
+			}
+		}
+	}
 	if flag&Lmsgprefix == 0 {
-		*buf = append(*buf, l.Prefix()...)
+		*buf = append(*buf, prefix...)
 	}
 	lm.Time(buf, t)
 	*buf = append(*buf, level...)
 	lm.File(buf)
 	if flag&Lmsgprefix != 0 {
-		*buf = append(*buf, l.Prefix()...)
+		*buf = append(*buf, prefix...)
 	}
 
 	*buf = append(*buf, " "...)
@@ -0,0 +1,76 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// FromEnv 根据 LOG_ 开头的环境变量配置日志实例，见 [FromEnvPrefix]
+func FromEnv() Options {
+	return FromEnvPrefix("LOG_")
+}
+
+// FromEnvPrefix 根据环境变量配置日志实例，未设置或为空的变量保持原有配置
+//
+//	<prefix>LEVEL     日志等级，格式见 [ParseLevel]
+//	<prefix>FORMAT    格式器名称，见 [Formatters]
+//	<prefix>FILE      输出到文件，替换原有的主输出
+//	<prefix>FLAGS     格式见 [ParseFlags]
+//	<prefix>VMODULE   格式见 [Log.SetModuleLevel]
+//	<prefix>NO_COLOR  为true时console格式器不输出颜色，也会遵循通用的 NO_COLOR 环境变量
+//
+// 格式错误的变量会被跳过，其余变量照常生效，所有错误一起返回
+func FromEnvPrefix(prefix string) Options {
+	return func(g *Log) error {
+		var errs []error
+		env := func(name string) (string, string) {
+			return prefix + name, os.Getenv(prefix + name)
+		}
+
+		if k, v := env("LEVEL"); v != "" {
+			if err := g.WithLevel(v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", k, err))
+			}
+		}
+		if k, v := env("FLAGS"); v != "" {
+			if flag, err := ParseFlags(v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", k, err))
+			} else {
+				g.SetFlag(flag)
+			}
+		}
+		if k, v := env("VMODULE"); v != "" {
+			if err := g.SetModuleLevel(v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", k, err))
+			}
+		}
+		if k, v := env("FORMAT"); v != "" {
+			if f, err := NewFormatter(v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", k, err))
+			} else {
+				g.SetFormatter(f)
+			}
+		}
+		noColor := os.Getenv("NO_COLOR") != ""
+		if k, v := env("NO_COLOR"); v != "" {
+			if b, err := strconv.ParseBool(v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid boolean %q", k, v))
+			} else {
+				noColor = b
+			}
+		}
+		if _, ok := g.formatter().(*consoleWriter); ok && noColor {
+			g.SetFormatter(&consoleWriter{noColor: true})
+		}
+		if k, v := env("FILE"); v != "" {
+			if f, err := NewRotatingFile(v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", k, err))
+			} else {
+				g.SetWriter(f)
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
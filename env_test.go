@@ -0,0 +1,52 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("APP_LOG_LEVEL", "warning")
+	t.Setenv("APP_LOG_FLAGS", "none")
+	t.Setenv("APP_LOG_FILE", path)
+	t.Setenv("APP_LOG_NO_COLOR", "true")
+
+	l := New(os.Stdout, "", 0)
+	if err := FromEnvPrefix("APP_LOG_")(l); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("hidden")
+	l.Warn("disk almost full")
+	l.Close()
+
+	b, _ := os.ReadFile(path)
+	if got := string(b); got != "[W] disk almost full\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestFromEnvErrors(t *testing.T) {
+	t.Setenv("APP_LOG_LEVEL", "eror")
+	t.Setenv("APP_LOG_FORMAT", "json")
+	t.Setenv("APP_LOG_FLAGS", "date|tme")
+	t.Setenv("APP_LOG_NO_COLOR", "maybe")
+
+	l := New(discard{}, "", 0)
+	err := FromEnvPrefix("APP_LOG_")(l)
+	if err == nil {
+		t.Fatal("FromEnvPrefix succeeded")
+	}
+	for _, want := range []string{"APP_LOG_LEVEL", "APP_LOG_FLAGS", "APP_LOG_NO_COLOR"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+	// 格式正确的变量照常生效
+	if _, ok := l.formatter().(*jsonFormat); !ok {
+		t.Errorf("formatter = %T, want *jsonFormat", l.formatter())
+	}
+}
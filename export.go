@@ -1,33 +1,36 @@
 package log
 
 import (
+	"errors"
 	"io"
 	"os"
-
-	"github.com/shiena/ansicolor"
+	"time"
 )
 
 var std *Log
 
 func init() {
-	std = new(Log)
-	std.level = debugLevel
-	std.opt = &Option{
+	std = newLog(&Option{
 		CallDepth:    4,
 		ShowFuncName: true,
 		Flag:         LstdFlags | Ltime | Lshortfile,
-	}
-	std.format = NewConsole()
-	std.writer = ansicolor.NewAnsiColorWriter(os.Stdout)
+	})
+	std.SetFormatter(NewConsole())
+	std.SetWriter(newColorWriter(os.Stdout))
 }
 
-type Options func(g *Log)
+// Options 默认日志实例的配置，返回的错误由 [Set] 汇总
+type Options func(g *Log) error
 
-// Set log
-func Set(options ...Options) { // level string, flag int,
+// Set 依次应用配置，返回所有失败配置的错误
+func Set(options ...Options) error {
+	var errs []error
 	for _, op := range options {
-		op(std)
+		if err := op(std); err != nil {
+			errs = append(errs, err)
+		}
 	}
+	return errors.Join(errs...)
 }
 
 // WithPath 设置日志输出路径
@@ -53,45 +56,109 @@ type Logger interface {
 	Fatal(msg any, a ...interface{})
 }
 
-// WithPath 设置日志输出路径
+// WithLevel 设置日志等级，level的格式见 [ParseLevel]
 func WithLevel(level string) Options {
-	return func(g *Log) {
-		g.SetLevel(getLevel(level))
+	return func(g *Log) error {
+		return g.WithLevel(level)
+	}
+}
+
+// WithFormatter 设置日志格式器
+func WithFormatter(f Formatter) Options {
+	return func(g *Log) error {
+		g.SetFormatter(f)
+		return nil
+	}
+}
+
+// WithSampler 设置采样器
+func WithSampler(s Sampler) Options {
+	return func(g *Log) error {
+		g.SetSampler(s)
+		return nil
+	}
+}
+
+// WithDedup 开启重复日志合并
+func WithDedup(window time.Duration) Options {
+	return func(g *Log) error {
+		g.SetDedup(window)
+		return nil
 	}
 }
 
 // Debug Debug
 func Debug(format any, a ...interface{}) {
-	std.Debug(format, a...)
+	std.print(nil, debugLevel, format, a...)
 }
 
 // Info Info
 func Info(format any, a ...interface{}) {
-	std.Info(format, a...)
+	std.print(nil, infoLevel, format, a...)
 }
 
 // Warn Warn
 func Warn(format any, a ...interface{}) {
-	std.Warn(format, a...)
+	std.print(nil, warnLevel, format, a...)
 }
 
 // Error Error
 func Error(format any, a ...interface{}) {
-	std.Error(format, a...)
+	std.print(nil, errorLevel, format, a...)
 }
 
 // Error Error
 func Panic(format any, a ...interface{}) {
-	std.Panic(format, a...)
+	std.print(nil, panicLevel, format, a...)
 }
 
 // Fatal Fatal
 func Fatal(format any, a ...interface{}) {
-	std.Fatal(format, a...)
+	std.print(nil, fatalLevel, format, a...)
+}
+
+// With 返回一个携带结构化字段的默认日志子实例
+func With(keyvals ...any) *Log {
+	return std.With(keyvals...)
+}
+
+// Debugw Debugw
+func Debugw(msg string, keyvals ...any) {
+	std.With(keyvals...).print(nil, debugLevel, msg)
+}
+
+// Infow Infow
+func Infow(msg string, keyvals ...any) {
+	std.With(keyvals...).print(nil, infoLevel, msg)
+}
+
+// Warnw Warnw
+func Warnw(msg string, keyvals ...any) {
+	std.With(keyvals...).print(nil, warnLevel, msg)
+}
+
+// Errorw Errorw
+func Errorw(msg string, keyvals ...any) {
+	std.With(keyvals...).print(nil, errorLevel, msg)
+}
+
+// Panicw Panicw
+func Panicw(msg string, keyvals ...any) {
+	std.With(keyvals...).print(nil, panicLevel, msg)
+}
+
+// Fatalw Fatalw
+func Fatalw(msg string, keyvals ...any) {
+	std.With(keyvals...).print(nil, fatalLevel, msg)
+}
+
+// Close 刷新并关闭默认日志实例的所有输出
+func Close() error {
+	return std.Close()
 }
 
 // 为指定等级的日志设置额外的输出
 // 通常用于需要特别关注的紧急日志
-func SetLevelWriter(level string, w ...io.Writer) {
-	std.SetLevelWriter(level, w...)
+func SetLevelWriter(level string, w ...io.Writer) error {
+	return std.SetLevelWriter(level, w...)
 }
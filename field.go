@@ -0,0 +1,114 @@
+package log
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+)
+
+// badKey 奇数个键值参数时，最后一个值使用的键名
+const badKey = "!BADKEY"
+
+// Field 结构化日志字段
+type Field struct {
+	Key   string
+	Value any
+}
+
+// F 创建一个结构化字段
+func F(key string, value any) Field {
+	return Field{Key: key, Value: value}
+}
+
+// appendFields 将交替的键值参数追加到fields中
+// 参数中可以直接混入Field，键不是字符串时使用fmt.Sprint转换
+func appendFields(fields []Field, keyvals []any) []Field {
+	// 避免修改父日志实例共享的底层数组
+	fields = slices.Clip(fields)
+	for i := 0; i < len(keyvals); i++ {
+		switch k := keyvals[i].(type) {
+		case Field:
+			fields = append(fields, k)
+			continue
+		case []Field:
+			fields = append(fields, k...)
+			continue
+		}
+		if i == len(keyvals)-1 {
+			fields = append(fields, Field{Key: badKey, Value: keyvals[i]})
+			break
+		}
+		key, ok := keyvals[i].(string)
+		if !ok {
+			key = fmt.Sprint(keyvals[i])
+		}
+		fields = append(fields, Field{Key: key, Value: keyvals[i+1]})
+		i++
+	}
+	return fields
+}
+
+// appendTextFields 以 key=value 的形式追加字段，每个字段前有一个空格
+func appendTextFields(buf *[]byte, fields []Field) {
+	for _, f := range fields {
+		*buf = append(*buf, ' ')
+		*buf = append(*buf, f.Key...)
+		*buf = append(*buf, '=')
+		appendTextValue(buf, f.Value)
+	}
+}
+
+// appendTextValue 追加字段值，包含空白、引号或等号的字符串会被加上引号
+func appendTextValue(buf *[]byte, v any) {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case int:
+		*buf = strconv.AppendInt(*buf, int64(v), 10)
+		return
+	case int64:
+		*buf = strconv.AppendInt(*buf, v, 10)
+		return
+	case uint64:
+		*buf = strconv.AppendUint(*buf, v, 10)
+		return
+	case bool:
+		*buf = strconv.AppendBool(*buf, v)
+		return
+	case error:
+		s = v.Error()
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		s = fmt.Sprint(v)
+	}
+	if needsQuote(s) {
+		*buf = strconv.AppendQuote(*buf, s)
+		return
+	}
+	*buf = append(*buf, s...)
+}
+
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b < utf8.RuneSelf {
+			if b <= ' ' || b == '=' || b == '"' || b == 0x7f {
+				return true
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError {
+			return true
+		}
+		i += size
+	}
+	return false
+}
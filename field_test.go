@@ -0,0 +1,39 @@
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWith(t *testing.T) {
+	var b strings.Builder
+	l := New(&b, "", 0)
+	l.SetFormatter(&DefaultFormat{})
+
+	child := l.With("user", 42, "req", "abc")
+	child.Info("hello %d", 1)
+	child.Infow("login", "ip", "10.0.0.1", "agent", "go client")
+	l.Info("parent")
+	l.SetLevel(warnLevel)
+	child.Info("filtered by parent level")
+
+	want := "[info ] hello 1 user=42 req=abc\n" +
+		"[info ] login user=42 req=abc ip=10.0.0.1 agent=\"go client\"\n" +
+		"[info ] parent\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestAppendFields(t *testing.T) {
+	base := appendFields(nil, []any{"a", 1})
+	x := appendFields(base, []any{"b", 2})
+	y := appendFields(base, []any{F("c", 3), "odd"})
+
+	if len(x) != 2 || x[1].Key != "b" {
+		t.Errorf("unexpected fields %v", x)
+	}
+	if len(y) != 3 || y[1].Key != "c" || y[2].Key != badKey || y[2].Value != "odd" {
+		t.Errorf("unexpected fields %v", y)
+	}
+}
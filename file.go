@@ -1,11 +1,20 @@
 package log
 
 import (
+	"compress/gzip"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
+// FileWrite 以追加方式打开日志文件，打开失败时panic
+//
+// Deprecated: use [NewRotatingFile], which reports errors and can rotate the file.
 func FileWrite(path string) io.Writer {
 	_ = CreateDirIfNotExists(filepath.Dir(path))
 	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0666)
@@ -32,3 +41,398 @@ func Exists(path string) bool {
 	}
 	return true
 }
+
+// RotateInterval 按时间切割日志文件的周期
+type RotateInterval int
+
+const (
+	RotateNever  RotateInterval = iota // 不按时间切割
+	RotateHourly                       // 每小时切割
+	RotateDaily                        // 每天切割
+)
+
+// backupTimeFormat 备份文件名中的时间格式，例如 app-2009-01-23T01-23-23.000.log
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotatingFile 按大小或时间切割的日志文件，可以直接用于 [Log.SetWriter] 和 [Log.SetLevelWriter]
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	interval   RotateInterval
+	maxBackups int
+	maxAge     time.Duration
+	compress   bool
+	now        func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	start  time.Time // 当前文件所属切割周期的开始时刻，按时间切割时用作备份文件名
+	next   time.Time // 下一次按时间切割的时刻
+	closed bool
+
+	millOnce sync.Once
+	millCh   chan struct{}
+	millDone chan struct{}
+}
+
+// RotateOption 切割配置
+type RotateOption func(f *RotatingFile)
+
+// WithMaxSize 单个文件超过size字节后切割，0表示不限制
+func WithMaxSize(size int64) RotateOption {
+	return func(f *RotatingFile) {
+		f.maxSize = size
+	}
+}
+
+// WithRotateInterval 按小时或天切割，备份文件以它所属周期的开始时刻命名，空文件不切割
+func WithRotateInterval(interval RotateInterval) RotateOption {
+	return func(f *RotatingFile) {
+		f.interval = interval
+	}
+}
+
+// WithMaxBackups 最多保留n个备份文件，0表示不限制
+func WithMaxBackups(n int) RotateOption {
+	return func(f *RotatingFile) {
+		f.maxBackups = n
+	}
+}
+
+// WithMaxAge 删除超过age的备份文件，0表示不限制
+func WithMaxAge(age time.Duration) RotateOption {
+	return func(f *RotatingFile) {
+		f.maxAge = age
+	}
+}
+
+// WithCompress 在后台使用gzip压缩切割出来的备份文件
+func WithCompress() RotateOption {
+	return func(f *RotatingFile) {
+		f.compress = true
+	}
+}
+
+// NewRotatingFile 打开或创建path指向的日志文件
+func NewRotatingFile(path string, options ...RotateOption) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, now: time.Now}
+	for _, op := range options {
+		op(f)
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write implements io.Writer.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// ConcurrentSafe implements ConcurrentWriter.
+func (f *RotatingFile) ConcurrentSafe() {}
+
+// reconfigure 替换切割配置，用于重新加载配置时路径不变的文件
+// 切割周期改变时，按文件的最后修改时间重新计算当前周期，与打开已有文件时相同
+func (f *RotatingFile) reconfigure(options []RotateOption) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	interval := f.interval
+	f.maxSize, f.interval, f.maxBackups, f.maxAge, f.compress = 0, RotateNever, 0, 0, false
+	for _, op := range options {
+		op(f)
+	}
+	if f.interval != interval {
+		start := f.now()
+		if f.file != nil && f.size > 0 {
+			if info, err := f.file.Stat(); err == nil {
+				start = info.ModTime()
+			}
+		}
+		f.start, f.next = f.periodStart(start), f.boundary(start)
+	}
+	if !f.closed {
+		f.mill()
+	}
+}
+
+// Rotate 立即切割当前文件
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// Sync 将缓存的数据写入磁盘
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// Close implements io.Closer，会等待后台的压缩和清理完成
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.mu.Unlock()
+
+	if f.millCh != nil {
+		close(f.millCh)
+		<-f.millDone
+	}
+	return err
+}
+
+func (f *RotatingFile) open() error {
+	if err := CreateDirIfNotExists(filepath.Dir(f.path)); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	// 已有内容的文件从最后修改时间开始计算切割周期，避免跨周期的内容留在同一个文件中
+	start := f.now()
+	if f.size > 0 {
+		start = info.ModTime()
+	}
+	f.start, f.next = f.periodStart(start), f.boundary(start)
+	return nil
+}
+
+// shouldRotate 判断写入n字节之前是否需要切割，需要持有mu
+// 空文件不按时间切割，直接进入当前的周期
+func (f *RotatingFile) shouldRotate(n int64) bool {
+	if f.maxSize > 0 && f.size > 0 && f.size+n > f.maxSize {
+		return true
+	}
+	if f.next.IsZero() {
+		return false
+	}
+	now := f.now()
+	if now.Before(f.next) {
+		return false
+	}
+	if f.size == 0 {
+		f.start, f.next = f.periodStart(now), f.boundary(now)
+		return false
+	}
+	return true
+}
+
+// periodStart 返回t所在切割周期的开始时刻，不按时间切割时返回零值
+func (f *RotatingFile) periodStart(t time.Time) time.Time {
+	switch f.interval {
+	case RotateHourly:
+		// Truncate按绝对时间取整，UTC偏移不是整小时的时区需要按本地时间计算
+		y, m, d := t.Date()
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, t.Location())
+	case RotateDaily:
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Time{}
+	}
+}
+
+// boundary 返回t之后的下一个切割时刻
+func (f *RotatingFile) boundary(t time.Time) time.Time {
+	switch start := f.periodStart(t); f.interval {
+	case RotateHourly:
+		return start.Add(time.Hour)
+	case RotateDaily:
+		return start.AddDate(0, 0, 1)
+	default:
+		return time.Time{}
+	}
+}
+
+func (f *RotatingFile) rotate() error {
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return err
+		}
+		f.file = nil
+	}
+	// 按时间切割时以文件所属周期的开始时刻命名，否则使用切割的时刻
+	// 同一时刻已有备份时顺延时间戳，避免覆盖
+	t := f.start
+	if t.IsZero() {
+		t = f.now()
+	}
+	for Exists(f.backupName(t)) || Exists(f.backupName(t)+".gz") {
+		t = t.Add(time.Millisecond)
+	}
+	if err := os.Rename(f.path, f.backupName(t)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.mill()
+	return nil
+}
+
+// backupName 生成备份文件名，时间戳插入在扩展名之前
+func (f *RotatingFile) backupName(t time.Time) string {
+	dir, prefix, ext := f.nameParts()
+	return filepath.Join(dir, prefix+t.Format(backupTimeFormat)+ext)
+}
+
+func (f *RotatingFile) nameParts() (dir, prefix, ext string) {
+	dir, name := filepath.Split(f.path)
+	ext = filepath.Ext(name)
+	prefix = strings.TrimSuffix(name, ext) + "-"
+	return dir, prefix, ext
+}
+
+// mill 通知后台协程压缩和清理备份文件
+func (f *RotatingFile) mill() {
+	if !f.compress && f.maxBackups == 0 && f.maxAge == 0 {
+		return
+	}
+	f.millOnce.Do(func() {
+		f.millCh = make(chan struct{}, 1)
+		f.millDone = make(chan struct{})
+		go f.millRun()
+	})
+	select {
+	case f.millCh <- struct{}{}:
+	default:
+	}
+}
+
+func (f *RotatingFile) millRun() {
+	defer close(f.millDone)
+	for range f.millCh {
+		_ = f.clean()
+	}
+}
+
+type backupFile struct {
+	path string
+	t    time.Time
+}
+
+// backups 返回所有备份文件，按时间从新到旧排序
+func (f *RotatingFile) backups() ([]backupFile, error) {
+	dir, prefix, ext := f.nameParts()
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []backupFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimSuffix(name[len(prefix):], ".gz"), ext)
+		t, err := time.ParseInLocation(backupTimeFormat, ts, time.Local)
+		if err != nil {
+			continue
+		}
+		files = append(files, backupFile{path: filepath.Join(dir, name), t: t})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].t.After(files[j].t) })
+	return files, nil
+}
+
+// clean 删除多余或过期的备份，并压缩剩余未压缩的备份
+func (f *RotatingFile) clean() error {
+	files, err := f.backups()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	cutoff := f.now().Add(-f.maxAge)
+	for i, b := range files {
+		if (f.maxBackups > 0 && i >= f.maxBackups) || (f.maxAge > 0 && b.t.Before(cutoff)) {
+			errs = append(errs, os.Remove(b.path))
+			continue
+		}
+		if f.compress && !strings.HasSuffix(b.path, ".gz") {
+			errs = append(errs, compressFile(b.path))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// compressFile 将文件压缩为 path.gz 并删除原文件
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(path + ".gz")
+		}
+	}()
+
+	zw := gzip.NewWriter(dst)
+	if _, err = io.Copy(zw, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = zw.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	_ = src.Close()
+	return os.Remove(path)
+}
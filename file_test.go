@@ -0,0 +1,117 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileSize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := NewRotatingFile(path, WithMaxSize(10), WithMaxBackups(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"line 1\n", "line 2\n", "line 3\n", "line 4\n"} {
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("x")); err != os.ErrClosed {
+		t.Errorf("write after close: got %v, want %v", err, os.ErrClosed)
+	}
+
+	b, _ := os.ReadFile(path)
+	if string(b) != "line 4\n" {
+		t.Errorf("current file: got %q", b)
+	}
+	backups, err := f.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("got %d backups, want 2", len(backups))
+	}
+	b, _ = os.ReadFile(backups[0].path)
+	if string(b) != "line 3\n" {
+		t.Errorf("newest backup: got %q", b)
+	}
+}
+
+func TestRotatingFileInterval(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	now := time.Date(2024, 1, 1, 23, 59, 0, 0, time.Local)
+	f := &RotatingFile{path: path, interval: RotateDaily, compress: true, now: func() time.Time { return now }}
+	if err := f.open(); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte("day 1\n"))
+	now = now.Add(2 * time.Minute)
+	_, _ = f.Write([]byte("day 2\n"))
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := os.ReadFile(path)
+	if string(b) != "day 2\n" {
+		t.Errorf("current file: got %q", b)
+	}
+	backups, _ := f.backups()
+	if len(backups) != 1 || !strings.HasSuffix(backups[0].path, "app-2024-01-01T00-00-00.000.log.gz") {
+		t.Errorf("unexpected backups %v", backups)
+	}
+}
+
+func TestRotatingFileIntervalEmpty(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	now := time.Date(2024, 1, 1, 10, 30, 0, 0, time.Local)
+	f := &RotatingFile{path: path, interval: RotateHourly, maxSize: 8, now: func() time.Time { return now }}
+	if err := f.open(); err != nil {
+		t.Fatal(err)
+	}
+	// 空文件跨越周期时不切割
+	now = now.Add(2 * time.Hour)
+	_, _ = f.Write([]byte("hour 12\n"))
+	_, _ = f.Write([]byte("size\n"))
+	now = now.Add(time.Hour)
+	_, _ = f.Write([]byte("hour 13\n"))
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, _ := f.backups()
+	var names []string
+	for _, b := range backups {
+		names = append(names, filepath.Base(b.path))
+	}
+	// 同一周期内按大小切割出的备份按顺序顺延
+	want := []string{"app-2024-01-01T12-00-00.001.log", "app-2024-01-01T12-00-00.000.log"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("got backups %v, want %v", names, want)
+	}
+	b, _ := os.ReadFile(backups[0].path)
+	if string(b) != "size\n" {
+		t.Errorf("newest backup: got %q", b)
+	}
+}
+
+func TestRotatingFileHourlyOffset(t *testing.T) {
+	// UTC+5:30
+	loc := time.FixedZone("IST", 5*3600+1800)
+	f := &RotatingFile{interval: RotateHourly}
+	now := time.Date(2024, 1, 1, 10, 15, 0, 0, loc)
+	if got, want := f.periodStart(now), time.Date(2024, 1, 1, 10, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("periodStart = %v, want %v", got, want)
+	}
+	if got, want := f.boundary(now), time.Date(2024, 1, 1, 11, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("boundary = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,131 @@
+package log
+
+import (
+	"flag"
+	"strings"
+)
+
+// RegisterFlags 在fs上注册日志相关的命令行参数，解析时直接作用于l
+//
+//	-log.level    日志等级，格式见 [ParseLevel]
+//	-log.format   格式器名称，见 [Formatters]
+//	-log.file     输出到文件，替换原有的主输出
+//	-log.flags    格式见 [ParseFlags]
+//	-log.vmodule  格式见 [Log.SetModuleLevel]
+//
+// fs为nil时注册到 [flag.CommandLine]
+func RegisterFlags(fs *flag.FlagSet, l *Log) {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	fs.Var(&levelFlag{l: l}, "log.level", "log level: debug, info, warn, error, panic or fatal")
+	fs.Var(&formatFlag{l: l}, "log.format", "log formatter: "+strings.Join(Formatters(), ", "))
+	fs.Var(&fileFlag{l: l}, "log.file", "write logs to this file instead of the default output")
+	fs.Var(&flagsFlag{l: l}, "log.flags", `log header flags separated by "|", e.g. date|time|shortfile`)
+	fs.Var(&vmoduleFlag{l: l}, "log.vmodule", `per-module log levels, e.g. "net/*=debug,db=warn"`)
+}
+
+// flag包会用零值调用String来判断默认值，因此所有String方法都要处理l为nil的情况
+
+type levelFlag struct{ l *Log }
+
+func (f *levelFlag) String() string {
+	if f.l == nil {
+		return ""
+	}
+	return f.l.Level().String()
+}
+
+func (f *levelFlag) Set(s string) error {
+	return f.l.WithLevel(s)
+}
+
+type formatFlag struct {
+	l    *Log
+	name string // 通过参数设置的格式器名称，格式器本身没有名称
+}
+
+func (f *formatFlag) String() string {
+	return f.name
+}
+
+func (f *formatFlag) Set(s string) error {
+	fm, err := NewFormatter(s)
+	if err != nil {
+		return err
+	}
+	f.l.SetFormatter(fm)
+	f.name = s
+	return nil
+}
+
+type fileFlag struct {
+	l    *Log
+	path string
+	w    *RotatingFile // 重复设置时关闭之前打开的文件
+}
+
+func (f *fileFlag) String() string {
+	return f.path
+}
+
+func (f *fileFlag) Set(s string) error {
+	w, err := NewRotatingFile(s)
+	if err != nil {
+		return err
+	}
+	f.l.SetWriter(w)
+	if f.w != nil {
+		// 等待正在写入旧文件的日志完成
+		f.l.reloadMu.Lock()
+		f.l.reloadMu.Unlock()
+		_ = f.w.Close()
+	}
+	f.path, f.w = s, w
+	return nil
+}
+
+type flagsFlag struct{ l *Log }
+
+func (f *flagsFlag) String() string {
+	if f.l == nil {
+		return ""
+	}
+	return flagString(f.l.option().Flag)
+}
+
+func (f *flagsFlag) Set(s string) error {
+	flag, err := ParseFlags(s)
+	if err != nil {
+		return err
+	}
+	f.l.SetFlag(flag)
+	return nil
+}
+
+type vmoduleFlag struct{ l *Log }
+
+func (f *vmoduleFlag) String() string {
+	if f.l == nil {
+		return ""
+	}
+	return f.l.ModuleLevel()
+}
+
+func (f *vmoduleFlag) Set(s string) error {
+	return f.l.SetModuleLevel(s)
+}
+
+// flagString 返回flag的名称，与 [ParseFlags] 互逆
+func flagString(flag int) string {
+	if flag == 0 {
+		return "none"
+	}
+	var names []string
+	for _, name := range []string{"date", "time", "microseconds", "longfile", "shortfile", "utc", "msgprefix"} {
+		if flag&flagNames[name] != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "|")
+}
@@ -0,0 +1,52 @@
+package log
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegisterFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l := New(discard{}, "", 0)
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	RegisterFlags(fs, l)
+
+	err := fs.Parse([]string{"-log.level=warning", "-log.format", "logfmt", "-log.flags=none", "-log.file", path, "-log.vmodule=db=error"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Level() != LevelWarn || l.ModuleLevel() != "db=error" {
+		t.Errorf("level %v, vmodule %q", l.Level(), l.ModuleLevel())
+	}
+	if got := fs.Lookup("log.flags").Value.String(); got != "none" {
+		t.Errorf("log.flags = %q", got)
+	}
+	l.Warn("ready")
+	l.Close()
+	if b, _ := os.ReadFile(path); string(b) != "level=warn msg=ready\n" {
+		t.Errorf("got %q", b)
+	}
+}
+
+func TestRegisterFlagsErrors(t *testing.T) {
+	l := New(discard{}, "", 0)
+	l.SetFlag(Ldate | Ltime | Lshortfile)
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	var usage bytes.Buffer
+	fs.SetOutput(&usage)
+	RegisterFlags(fs, l)
+
+	fs.PrintDefaults()
+	if !strings.Contains(usage.String(), `(default date|time|shortfile)`) || !strings.Contains(usage.String(), "-log.level") {
+		t.Errorf("usage:\n%s", usage.String())
+	}
+	for _, arg := range []string{"-log.level=eror", "-log.format=xml", "-log.flags=tme", "-log.vmodule=db", "-log.file=" + t.TempDir()} {
+		if err := fs.Parse([]string{arg}); err == nil {
+			t.Errorf("%s accepted", arg)
+		}
+	}
+}
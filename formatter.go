@@ -0,0 +1,55 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FormatterFactory 创建格式器的函数
+type FormatterFactory func() Formatter
+
+var (
+	formattersMu sync.RWMutex
+	formatters   = map[string]FormatterFactory{
+		"console": func() Formatter { return NewConsole() },
+		"text":    func() Formatter { return &DefaultFormat{} },
+		"json":    func() Formatter { return NewJSON() },
+		"logfmt":  func() Formatter { return NewLogfmt() },
+	}
+)
+
+// RegisterFormatter 注册一个具名的格式器，便于通过配置文件按名称选择
+// 名称不区分大小写，重复注册会覆盖之前的格式器
+func RegisterFormatter(name string, factory FormatterFactory) {
+	if factory == nil {
+		panic("log: RegisterFormatter factory is nil")
+	}
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+	formatters[strings.ToLower(name)] = factory
+}
+
+// NewFormatter 根据名称创建已注册的格式器
+func NewFormatter(name string) (Formatter, error) {
+	formattersMu.RLock()
+	factory, ok := formatters[strings.ToLower(name)]
+	formattersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("log: unknown formatter %q", name)
+	}
+	return factory(), nil
+}
+
+// Formatters 返回所有已注册的格式器名称
+func Formatters() []string {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -3,3 +3,5 @@ module github.com/nbcx/log
 go 1.23
 
 require github.com/shiena/ansicolor v0.0.0-20230509054315-a9deabde6e02
+
+require gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,197 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// levelState 日志等级状态，用于展示和自动恢复
+type levelState struct {
+	Name     string `json:"name,omitempty"`
+	Level    string `json:"level"`
+	Explicit bool   `json:"explicit"` // 是否单独设置了等级，为false时继承上一级
+}
+
+// levelResponse GET请求以及修改成功后返回的内容
+type levelResponse struct {
+	Level    string       `json:"level"`
+	Loggers  []levelState `json:"loggers"`
+	RevertAt *time.Time   `json:"revert_at,omitempty"`
+}
+
+// levelRequest PUT和POST请求的内容，Duration大于0时到期恢复原来的等级，例如 "5m"
+type levelRequest struct {
+	Level    string            `json:"level"`
+	Loggers  map[string]string `json:"loggers"`
+	Duration string            `json:"duration"`
+}
+
+// levelHandler 查看和修改日志等级的HTTP接口
+type levelHandler struct {
+	l *Log
+
+	mu       sync.Mutex
+	timer    *time.Timer
+	gen      uint64 // 每次启动定时器时递增，已经开始执行的过期定时器据此跳过恢复
+	revertAt time.Time
+	saved    map[string]levelState // 修改前的状态，按名称索引，根日志实例的名称为空
+}
+
+// LevelHandler 返回一个查看和修改l及其具名子实例日志等级的 [http.Handler]
+//
+//	GET            返回当前等级，例如 {"level":"info","loggers":[{"name":"app.db","level":"debug","explicit":true}]}
+//	PUT/POST       修改等级，例如 {"level":"debug","loggers":{"app.db":"warn"},"duration":"5m"}
+//
+// 指定duration时，到期后恢复为修改前的等级，期间再次修改会延长恢复时间并保留最初的等级
+// loggers中只能指定已经通过 [Log.Named] 创建的日志实例，名称相对于l
+func LevelHandler(l *Log) http.Handler {
+	return &levelHandler{l: l}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var req levelRequest
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			httpError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+		if err := h.apply(req); err != nil {
+			httpError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(h.state())
+}
+
+// apply 校验并修改等级
+func (h *levelHandler) apply(req levelRequest) error {
+	var d time.Duration
+	if req.Duration != "" {
+		var err error
+		if d, err = time.ParseDuration(req.Duration); err != nil || d < 0 {
+			return fmt.Errorf("invalid duration %q", req.Duration)
+		}
+	}
+	type change struct {
+		target *Log
+		level  Level
+	}
+	changes := make(map[string]change, len(req.Loggers)+1)
+	if req.Level != "" {
+		lv, err := ParseLevel(req.Level)
+		if err != nil {
+			return err
+		}
+		changes[""] = change{h.l, lv}
+	}
+	for name, level := range req.Loggers {
+		if strings.Trim(name, ".") == "" {
+			return fmt.Errorf("invalid logger name %q", name)
+		}
+		target := h.l.lookup(name)
+		if target == nil {
+			return fmt.Errorf("unknown logger %q", name)
+		}
+		lv, err := ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("logger %q: %w", name, err)
+		}
+		changes[name] = change{target, lv}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if d > 0 && h.saved == nil {
+		h.saved = make(map[string]levelState)
+	}
+	for name, c := range changes {
+		target, lv := c.target, c.level
+		if d == 0 {
+			// 不带duration的修改是永久的，不再参与恢复
+			delete(h.saved, name)
+		} else if _, ok := h.saved[name]; !ok {
+			h.saved[name] = levelState{Level: target.Level().String(), Explicit: target.LevelSet()}
+		}
+		target.SetLevel(lv)
+	}
+	if d > 0 {
+		if h.timer != nil {
+			h.timer.Stop()
+		}
+		h.gen++
+		gen := h.gen
+		h.revertAt = time.Now().Add(d)
+		h.timer = time.AfterFunc(d, func() { h.revert(gen) })
+	}
+	return nil
+}
+
+// revert 恢复修改前的等级，gen不是最新的定时器时说明恢复时间已经被延长，直接跳过
+func (h *levelHandler) revert(gen uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if gen != h.gen {
+		return
+	}
+	for name, st := range h.saved {
+		target := h.l
+		if name != "" {
+			target = h.l.lookup(name)
+		}
+		if st.Explicit {
+			lv, _ := ParseLevel(st.Level)
+			target.SetLevel(lv)
+		} else {
+			target.ResetLevel()
+		}
+	}
+	h.saved = nil
+	h.timer = nil
+	h.revertAt = time.Time{}
+}
+
+func (h *levelHandler) state() levelResponse {
+	resp := levelResponse{Level: h.l.Level().String(), Loggers: []levelState{}}
+	prefix := ""
+	if h.l.Name() != "" {
+		prefix = h.l.Name() + "."
+	}
+	for _, n := range h.l.Loggers() {
+		if !strings.HasPrefix(n.Name(), prefix) {
+			continue
+		}
+		resp.Loggers = append(resp.Loggers, levelState{
+			Name:     strings.TrimPrefix(n.Name(), prefix),
+			Level:    n.Level().String(),
+			Explicit: n.LevelSet(),
+		})
+	}
+	h.mu.Lock()
+	if !h.revertAt.IsZero() {
+		t := h.revertAt
+		resp.RevertAt = &t
+	}
+	h.mu.Unlock()
+	return resp
+}
+
+func httpError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
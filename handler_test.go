@@ -0,0 +1,99 @@
+package log
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func doLevelRequest(t *testing.T, h http.Handler, method, body string) (int, levelResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, "/log/level", strings.NewReader(body)))
+	var resp levelResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec.Code, resp
+}
+
+func TestLevelHandler(t *testing.T) {
+	l := New(io.Discard, "", 0)
+	l.SetLevel(infoLevel)
+	l.Named("app.db")
+	h := LevelHandler(l)
+
+	code, resp := doLevelRequest(t, h, http.MethodGet, "")
+	if code != http.StatusOK || resp.Level != "info" || len(resp.Loggers) != 2 || resp.Loggers[1].Explicit {
+		t.Fatalf("GET: %d %+v", code, resp)
+	}
+
+	code, resp = doLevelRequest(t, h, http.MethodPut, `{"level":"warn","loggers":{"app.db":"debug"}}`)
+	if code != http.StatusOK || resp.Level != "warn" || resp.Loggers[0].Level != "warn" || resp.Loggers[1] != (levelState{Name: "app.db", Level: "debug", Explicit: true}) {
+		t.Fatalf("PUT: %d %+v", code, resp)
+	}
+
+	for _, body := range []string{`{"level":"verbose"}`, `{"loggers":{"":"info"}}`, `{"duration":"soon"}`, `{"lvl":"info"}`, `{"loggers":{"app.cache":"info"}}`} {
+		if code, _ := doLevelRequest(t, h, http.MethodPost, body); code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want %d", body, code, http.StatusBadRequest)
+		}
+	}
+	if code, _ := doLevelRequest(t, h, http.MethodDelete, ""); code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE: got %d", code)
+	}
+	if n := len(l.Loggers()); n != 2 {
+		t.Errorf("handler created loggers: %d", n)
+	}
+}
+
+func TestLevelHandlerRevert(t *testing.T) {
+	l := New(io.Discard, "", 0)
+	l.SetLevel(errorLevel)
+	l.Named("app")
+	h := LevelHandler(l)
+
+	code, resp := doLevelRequest(t, h, http.MethodPost, `{"level":"debug","loggers":{"app":"info"},"duration":"20ms"}`)
+	if code != http.StatusOK || resp.Level != "debug" || resp.RevertAt == nil {
+		t.Fatalf("POST: %d %+v", code, resp)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for l.Level() != errorLevel && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	_, resp = doLevelRequest(t, h, http.MethodGet, "")
+	if resp.Level != "error" || resp.RevertAt != nil || resp.Loggers[0] != (levelState{Name: "app", Level: "error"}) {
+		t.Errorf("after revert: %+v", resp)
+	}
+}
+
+func TestLevelHandlerStaleRevert(t *testing.T) {
+	l := New(io.Discard, "", 0)
+	l.SetLevel(errorLevel)
+	h := LevelHandler(l).(*levelHandler)
+
+	doLevelRequest(t, h, http.MethodPost, `{"level":"info","duration":"1h"}`)
+	h.mu.Lock()
+	stale := h.gen
+	h.mu.Unlock()
+	doLevelRequest(t, h, http.MethodPost, `{"level":"debug","duration":"1h"}`)
+
+	// 已经开始执行的旧定时器不能提前恢复等级
+	h.revert(stale)
+	if l.Level() != debugLevel {
+		t.Errorf("stale revert changed level to %v", l.Level())
+	}
+	h.mu.Lock()
+	current := h.gen
+	h.mu.Unlock()
+	h.revert(current)
+	if l.Level() != errorLevel {
+		t.Errorf("level after revert = %v, want error", l.Level())
+	}
+}
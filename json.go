@@ -0,0 +1,205 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// jsonFormat 每条日志输出一行JSON对象
+type jsonFormat struct{}
+
+// NewJSON creates a Formatter that writes one JSON object per line.
+func NewJSON() *jsonFormat {
+	return &jsonFormat{}
+}
+
+// Format implements log.Formatter.
+func (j *jsonFormat) Format(lm *Option, buf *[]byte, level int32, format string, args ...interface{}) {
+	now := time.Now()
+
+	*buf = append(*buf, '{')
+	if lm.hasTime() {
+		*buf = append(*buf, `"time":"`...)
+		lm.timestamp(buf, now)
+		*buf = append(*buf, `",`...)
+	}
+	*buf = append(*buf, `"level":"`...)
+	*buf = append(*buf, levelNames[level]...)
+	*buf = append(*buf, '"')
+	if prefix := lm.Prefix(); prefix != "" {
+		*buf = append(*buf, `,"logger":`...)
+		appendJSONString(buf, prefix)
+	}
+
+	msg := format
+	if len(args) > 0 {
+		msg = fmt.Sprintf(format, args...)
+	}
+	*buf = append(*buf, `,"msg":`...)
+	appendJSONString(buf, msg)
+
+	if lm.Flag&(Lshortfile|Llongfile) != 0 || lm.ShowFuncName {
+		frame := lm.Frame()
+		if lm.Flag&(Lshortfile|Llongfile) != 0 {
+			file := frame.File
+			if lm.Flag&Lshortfile != 0 {
+				file = shortFile(file)
+			}
+			*buf = append(*buf, `,"caller":"`...)
+			appendJSONEscaped(buf, file)
+			*buf = append(*buf, ':')
+			*buf = strconv.AppendInt(*buf, int64(frame.Line), 10)
+			*buf = append(*buf, '"')
+		}
+		if lm.ShowFuncName && frame.Function != "" {
+			*buf = append(*buf, `,"func":`...)
+			appendJSONString(buf, frame.Function)
+		}
+	}
+
+	for _, f := range lm.Fields() {
+		*buf = append(*buf, ',')
+		appendJSONString(buf, f.Key)
+		*buf = append(*buf, ':')
+		appendJSONValue(buf, f.Value)
+	}
+	*buf = append(*buf, '}', '\n')
+}
+
+// appendJSONValue 常见类型直接编码，其余类型交给encoding/json
+func appendJSONValue(buf *[]byte, v any) {
+	switch v := v.(type) {
+	case nil:
+		*buf = append(*buf, "null"...)
+	case string:
+		appendJSONString(buf, v)
+	case bool:
+		*buf = strconv.AppendBool(*buf, v)
+	case int:
+		*buf = strconv.AppendInt(*buf, int64(v), 10)
+	case int8:
+		*buf = strconv.AppendInt(*buf, int64(v), 10)
+	case int16:
+		*buf = strconv.AppendInt(*buf, int64(v), 10)
+	case int32:
+		*buf = strconv.AppendInt(*buf, int64(v), 10)
+	case int64:
+		*buf = strconv.AppendInt(*buf, v, 10)
+	case uint:
+		*buf = strconv.AppendUint(*buf, uint64(v), 10)
+	case uint8:
+		*buf = strconv.AppendUint(*buf, uint64(v), 10)
+	case uint16:
+		*buf = strconv.AppendUint(*buf, uint64(v), 10)
+	case uint32:
+		*buf = strconv.AppendUint(*buf, uint64(v), 10)
+	case uint64:
+		*buf = strconv.AppendUint(*buf, v, 10)
+	case float32:
+		appendJSONFloat(buf, float64(v), 32)
+	case float64:
+		appendJSONFloat(buf, v, 64)
+	case time.Duration:
+		appendJSONString(buf, v.String())
+	case time.Time:
+		*buf = append(*buf, '"')
+		*buf = v.AppendFormat(*buf, time.RFC3339Nano)
+		*buf = append(*buf, '"')
+	case error:
+		appendJSONString(buf, v.Error())
+	case json.Marshaler:
+		appendJSONMarshal(buf, v)
+	case fmt.Stringer:
+		appendJSONString(buf, v.String())
+	default:
+		appendJSONMarshal(buf, v)
+	}
+}
+
+func appendJSONMarshal(buf *[]byte, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		appendJSONString(buf, fmt.Sprint(v))
+		return
+	}
+	*buf = append(*buf, b...)
+}
+
+// appendJSONFloat NaN和Inf不是合法的JSON数字，以字符串输出
+func appendJSONFloat(buf *[]byte, f float64, bits int) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		appendJSONString(buf, strconv.FormatFloat(f, 'g', -1, bits))
+		return
+	}
+	*buf = strconv.AppendFloat(*buf, f, 'g', -1, bits)
+}
+
+func appendJSONString(buf *[]byte, s string) {
+	*buf = append(*buf, '"')
+	appendJSONEscaped(buf, s)
+	*buf = append(*buf, '"')
+}
+
+// appendJSONEscaped 按照JSON规则转义字符串，非法的UTF-8替换为U+FFFD
+func appendJSONEscaped(buf *[]byte, s string) {
+	start := 0
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' {
+				i++
+				continue
+			}
+			*buf = append(*buf, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				*buf = append(*buf, '\\', b)
+			case '\n':
+				*buf = append(*buf, '\\', 'n')
+			case '\r':
+				*buf = append(*buf, '\\', 'r')
+			case '\t':
+				*buf = append(*buf, '\\', 't')
+			default:
+				*buf = append(*buf, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			*buf = append(*buf, s[start:i]...)
+			*buf = append(*buf, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 break JavaScript parsers.
+		if r == '\u2028' || r == '\u2029' {
+			*buf = append(*buf, s[start:i]...)
+			*buf = append(*buf, '\\', 'u', '2', '0', '2', hexDigits[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	*buf = append(*buf, s[start:]...)
+}
+
+// shortFile 返回文件路径的最后一个元素
+func shortFile(file string) string {
+	for i := len(file) - 1; i > 0; i-- {
+		if file[i] == '/' {
+			return file[i+1:]
+		}
+	}
+	return file
+}
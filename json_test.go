@@ -0,0 +1,72 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestJSONFormat(t *testing.T) {
+	var b strings.Builder
+	l := New(&b, "", Ldate|Ltime|Lmicroseconds|LUTC|Lshortfile)
+	l.SetFormatter(NewJSON())
+
+	l.With("user", 42, "err", errors.New("boom")).Warn("hello \"%s\"\n", "world")
+
+	var m map[string]any
+	if err := json.Unmarshal([]byte(b.String()), &m); err != nil {
+		t.Fatalf("invalid json %q: %v", b.String(), err)
+	}
+	if m["level"] != "warn" || m["msg"] != "hello \"world\"\n" {
+		t.Errorf("unexpected level or msg: %v", m)
+	}
+	if m["user"] != float64(42) || m["err"] != "boom" {
+		t.Errorf("unexpected fields: %v", m)
+	}
+	if c, _ := m["caller"].(string); !strings.HasPrefix(c, "json_test.go:") {
+		t.Errorf("unexpected caller %q", c)
+	}
+	if f, _ := m["func"].(string); !strings.HasSuffix(f, "TestJSONFormat") {
+		t.Errorf("unexpected func %q", f)
+	}
+	if ts, _ := m["time"].(string); len(ts) != len("2006-01-02T15:04:05.000000Z") {
+		t.Errorf("unexpected time %q", ts)
+	}
+}
+
+func TestJSONFlags(t *testing.T) {
+	var b strings.Builder
+	l := New(&b, "", 0)
+	l.SetFormatter(NewJSON())
+	l.setOption(func(o *Option) { o.ShowFuncName = false })
+
+	l.Info("x")
+	if got, want := b.String(), `{"level":"info","msg":"x"}`+"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAppendJSONValue(t *testing.T) {
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{nil, `null`},
+		{"a\tb\x01\u2028\xff", `"a\tb\u0001\u2028` + "\ufffd" + `"`},
+		{int8(-3), `-3`},
+		{uint32(7), `7`},
+		{1.5, `1.5`},
+		{math.Inf(1), `"+Inf"`},
+		{[]int{1, 2}, `[1,2]`},
+		{map[string]int{"a": 1}, `{"a":1}`},
+	}
+	for _, tt := range tests {
+		var buf []byte
+		appendJSONValue(&buf, tt.v)
+		if string(buf) != tt.want {
+			t.Errorf("appendJSONValue(%#v) = %s, want %s", tt.v, buf, tt.want)
+		}
+	}
+}
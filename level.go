@@ -0,0 +1,72 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Level 日志等级
+type Level int32
+
+// 日志等级，数值越大越严重
+const (
+	LevelDebug Level = debugLevel
+	LevelInfo  Level = infoLevel
+	LevelWarn  Level = warnLevel
+	LevelError Level = errorLevel
+	LevelPanic Level = panicLevel
+	LevelFatal Level = fatalLevel
+)
+
+// levelAliases 等级名称的别名
+var levelAliases = map[string]Level{
+	"warning":  LevelWarn,
+	"err":      LevelError,
+	"crit":     LevelFatal,
+	"critical": LevelFatal,
+}
+
+// ParseLevel 解析日志等级，不区分大小写
+// 支持等级名称 debug、info、warn、error、panic、fatal，别名 warning、err、crit，以及数字 0-5
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	for i, n := range levelNames {
+		if n == name {
+			return Level(i), nil
+		}
+	}
+	if lv, ok := levelAliases[name]; ok {
+		return lv, nil
+	}
+	if n, err := strconv.Atoi(name); err == nil && n >= debugLevel && n <= fatalLevel {
+		return Level(n), nil
+	}
+	return 0, fmt.Errorf("log: unknown level %q", s)
+}
+
+// String 返回等级名称，未知的等级返回 Level(n)
+func (lv Level) String() string {
+	if lv >= LevelDebug && lv <= LevelFatal {
+		return levelNames[lv]
+	}
+	return "Level(" + strconv.Itoa(int(lv)) + ")"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (lv Level) MarshalText() ([]byte, error) {
+	if lv < LevelDebug || lv > LevelFatal {
+		return nil, fmt.Errorf("log: invalid level %d", int32(lv))
+	}
+	return []byte(levelNames[lv]), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (lv *Level) UnmarshalText(text []byte) error {
+	v, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*lv = v
+	return nil
+}
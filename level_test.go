@@ -0,0 +1,66 @@
+package log
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]Level{
+		"debug": LevelDebug, "INFO": LevelInfo, " warn ": LevelWarn, "warning": LevelWarn,
+		"error": LevelError, "err": LevelError, "panic": LevelPanic, "fatal": LevelFatal,
+		"crit": LevelFatal, "0": LevelDebug, "4": LevelPanic, "5": LevelFatal,
+	} {
+		if lv, err := ParseLevel(s); err != nil || lv != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", s, lv, err, want)
+		}
+	}
+	for _, s := range []string{"", "eror", "6", "-1", "trace"} {
+		if _, err := ParseLevel(s); err == nil {
+			t.Errorf("ParseLevel(%q) succeeded", s)
+		}
+	}
+}
+
+func TestLevelText(t *testing.T) {
+	if s := LevelPanic.String(); s != "panic" {
+		t.Errorf("String() = %q", s)
+	}
+	if s := Level(9).String(); s != "Level(9)" {
+		t.Errorf("String() = %q", s)
+	}
+
+	var cfg struct{ Level Level }
+	if err := json.Unmarshal([]byte(`{"Level":"warning"}`), &cfg); err != nil || cfg.Level != LevelWarn {
+		t.Fatalf("unmarshal: %v %v", cfg.Level, err)
+	}
+	if b, err := json.Marshal(cfg); err != nil || string(b) != `{"Level":"warn"}` {
+		t.Errorf("marshal: %s %v", b, err)
+	}
+	if err := json.Unmarshal([]byte(`{"Level":"eror"}`), &cfg); err == nil {
+		t.Error("unmarshal of unknown level succeeded")
+	}
+	if _, err := json.Marshal(struct{ Level Level }{Level(9)}); err == nil {
+		t.Error("marshal of invalid level succeeded")
+	}
+}
+
+func TestLevelErrors(t *testing.T) {
+	l := New(io.Discard, "", 0)
+	if err := l.WithLevel("eror"); err == nil {
+		t.Error("WithLevel accepted unknown level")
+	}
+	if l.Level() != LevelDebug {
+		t.Errorf("level changed to %v", l.Level())
+	}
+	if err := l.SetLevelWriter("fatl", io.Discard); err == nil {
+		t.Error("SetLevelWriter accepted unknown level")
+	}
+	if err := l.SetModuleLevel("db=eror"); err == nil {
+		t.Error("SetModuleLevel accepted unknown level")
+	}
+	if err := Set(WithLevel("bogus"), WithDedup(0)); err == nil {
+		t.Error("Set did not report WithLevel error")
+	}
+}
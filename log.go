@@ -1,30 +1,236 @@
 package log
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"reflect"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"sync/atomic"
 )
 
+// ErrClosed 重复关闭日志实例时返回
+var ErrClosed = errors.New("log: logger already closed")
+
 // Logger Logger
 type Log struct {
-	level  int32
-	opt    *Option
-	format Formatter
+	*core
+	fields []Field                    // 通过With添加的字段，创建后不再修改
+	prefix *atomic.Pointer[prefixVal] // 通过With派生的子实例共享，通过Named派生的子实例各自拥有
+	lv     *levelVar                  // 通过With派生的子实例共享，通过Named派生的子实例各自拥有
+	name   string
+}
+
+// prefixVal 日志实例的前缀
+type prefixVal struct {
+	s     string
+	named bool // 是Named的名称
+}
+
+// option 返回当前生效的配置，由共享的配置加上日志实例自身的前缀和字段组成，返回值可以修改
+func (l *Log) option() *Option {
+	return l.fill(l.shared.Load().clone())
+}
+
+// at 与option相同，但返回的是调用位置为pc的临时配置，用完后通过putOption归还
+func (l *Log) at(pc uintptr) *Option {
+	return l.fill(l.shared.Load().at(pc))
+}
+
+// fill 将日志实例自身的前缀和字段写入o
+func (l *Log) fill(o *Option) *Option {
+	o.prefix.Store(nil)
+	o.named = false
+	if p := l.prefix.Load(); p != nil {
+		o.prefix.Store(&p.s)
+		o.named = p.named
+	}
+	o.fields = l.fields
+	return o
+}
+
+// setOption 修改日志实例及其所有子实例共享的配置（flag、调用深度等）
+// 复制后整体替换，不影响正在使用旧配置的日志调用
+func (l *Log) setOption(fn func(o *Option)) {
+	l.optMu.Lock()
+	defer l.optMu.Unlock()
+	o := l.shared.Load().clone()
+	fn(o)
+	l.shared.Store(o)
+}
+
+// core 由同一日志实例及其通过With派生的子实例共享
+type core struct {
+	shared  atomic.Pointer[Option] // 共享的配置，前缀和字段由各日志实例自己保存
+	format  atomic.Pointer[Formatter]
+	out     atomic.Pointer[outputs]
+	sampler atomic.Pointer[Sampler]
+	dedup   atomic.Pointer[dedup]
+	vmodule atomic.Pointer[vmodule]
+
+	root    *levelVar
+	namesMu sync.Mutex // 保护names以及等级层级
+	names   map[string]*Log
+	mu      sync.Mutex // 串行化对输出的写入
+	setMu   sync.Mutex // 串行化对输出的修改
+	optMu   sync.Mutex // 串行化对各日志实例配置的修改
+	// 日志在格式化和写入期间持有读锁；整体应用配置时持有写锁，
+	// 使每条日志要么完全使用旧配置，要么完全使用新配置，释放写锁后旧的输出不再有写入
+	reloadMu sync.RWMutex
+
+	sampleArmed atomic.Bool // 是否已经启动输出采样汇总的定时器
+	closed      atomic.Bool
+}
+
+// outputs 输出的快照，修改时整体替换，写入时无需加锁读取
+type outputs struct {
 	writer io.Writer
 	extra  [fatalLevel + 1][]io.Writer
+	// safe[level] 表示该等级的所有输出都是并发安全的，写入时可以不加锁
+	safe [fatalLevel + 1]bool
+}
+
+// ConcurrentWriter 自身保证并发安全的输出，[Log] 写入时不再为它加锁
+type ConcurrentWriter interface {
+	io.Writer
+	ConcurrentSafe()
+}
+
+// concurrentWriter 将普通输出声明为并发安全
+type concurrentWriter struct {
+	io.Writer
+}
+
+func (concurrentWriter) ConcurrentSafe() {}
+
+// Concurrent 声明w是并发安全的，例如自带锁的输出，[Log] 写入时不再为它加锁
+func Concurrent(w io.Writer) io.Writer {
+	return concurrentWriter{w}
+}
+
+// unwrapWriter 去掉Concurrent的包装，返回底层输出以及它是否并发安全
+func unwrapWriter(w io.Writer) (io.Writer, bool) {
+	switch v := w.(type) {
+	case concurrentWriter:
+		return v.Writer, true
+	case ConcurrentWriter:
+		return w, true
+	}
+	return w, false
 }
 
-// Close It's dangerous to call the method on logging
-func (logger *Log) Close() {
-	// if logger.baseFile != nil {
-	// 	_ = logger.baseFile.Close()
-	// }
+// update 在setMu的保护下复制并修改输出快照
+func (c *core) update(fn func(o *outputs)) {
+	c.setMu.Lock()
+	defer c.setMu.Unlock()
 
-	// // logger.handle = nil
-	// logger.baseFile = nil
+	o := new(outputs)
+	if old := c.out.Load(); old != nil {
+		*o = *old
+	}
+	fn(o)
+	_, safe := unwrapWriter(o.writer)
+	for lv := range o.extra {
+		o.safe[lv] = safe
+		for _, w := range o.extra[lv] {
+			if _, ok := unwrapWriter(w); !ok {
+				o.safe[lv] = false
+			}
+		}
+	}
+	c.out.Store(o)
+}
+
+// output 将一条格式化好的日志写入所有输出，key用于合并重复日志
+// 调用方需要持有reloadMu，以保证Close不会在写入期间关闭输出
+func (c *core) output(level int32, p []byte, fields []Field, key dupKey) {
+	o := c.out.Load()
+	if o == nil {
+		return
+	}
+	// 合并重复日志时需要在锁内读写每个输出的状态
+	d := c.dedup.Load()
+	if !o.safe[level] || d != nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		d = c.dedup.Load()
+	}
+	if o.writer != nil {
+		c.write(d, o.writer, level, p, fields, key)
+	}
+	// 额外的日志输出通道
+	for _, w := range o.extra[level] {
+		c.write(d, w, level, p, fields, key)
+	}
+}
+
+// write 写入单个输出，开启合并时跳过重复的日志
+func (c *core) write(d *dedup, w io.Writer, level int32, p []byte, fields []Field, key dupKey) {
+	w, _ = unwrapWriter(w)
+	if d == nil || d.pass(c, w, level, key) {
+		_ = writeEntry(w, level, p, fields)
+	}
+}
+
+// flusher 带有缓冲的输出，例如 *bufio.Writer
+type flusher interface {
+	Flush() error
+}
+
+// Close 刷新并关闭所有输出，同一个输出只会被关闭一次，标准输出和标准错误不会被关闭
+// 关闭后的日志调用不再输出，但Panic和Fatal仍然会panic和退出
+// 重复调用返回 [ErrClosed]
+func (l *Log) Close() error {
+	if !l.closed.CompareAndSwap(false, true) {
+		return ErrClosed
+	}
+	// 等待正在进行的写入完成，包括不经过mu的并发安全输出
+	l.reloadMu.Lock()
+	defer l.reloadMu.Unlock()
+	l.flushAllSamples()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if d := l.dedup.Load(); d != nil {
+		d.flush(l.core)
+	}
+
+	var writers []io.Writer
+	if o := l.out.Load(); o != nil {
+		writers = append(writers, o.writer)
+		for _, ws := range o.extra {
+			writers = append(writers, ws...)
+		}
+	}
+
+	var errs []error
+	seen := make(map[io.Writer]bool)
+	for _, w := range writers {
+		w, _ = unwrapWriter(w)
+		if w == nil {
+			continue
+		}
+		if reflect.TypeOf(w).Comparable() {
+			if seen[w] {
+				continue
+			}
+			seen[w] = true
+		}
+		if f, ok := w.(flusher); ok {
+			errs = append(errs, f.Flush())
+		}
+		if w == io.Writer(os.Stdout) || w == io.Writer(os.Stderr) {
+			continue
+		}
+		if c, ok := w.(io.Closer); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
 }
 
 func formatPattern(f interface{}, v []interface{}) string {
@@ -49,20 +255,35 @@ func formatPattern(f interface{}, v []interface{}) string {
 	return msg
 }
 
-func (l *Log) print(level int32, format any, a ...interface{}) {
-	msg := formatPattern(format, a)
-	if level < atomic.LoadInt32(&l.level) {
-		return
+// print 输出一条日志，只能在公开的日志方法或包级函数中直接调用，调用位置根据CallDepth计算
+// ctx不为nil时，确定输出之后才从中提取字段附加到日志上
+func (l *Log) print(ctx context.Context, level int32, format any, a ...interface{}) {
+	var pc uintptr
+	if v := l.vmodule.Load(); v == nil {
+		if level < l.lv.level.Load() {
+			return
+		}
+	} else {
+		pc = l.caller()
+		if !l.enabled(v, pc, level) {
+			return
+		}
 	}
-	if l.format == nil {
-		panic("logger closed case format is nil")
+	msg := formatPattern(format, a)
+	if pc == 0 && l.sampler.Load() != nil {
+		pc = l.caller()
 	}
-
-	s := l.format.Format(l.opt, level, msg, a...)
-	_, _ = l.writer.Write(s)
-	// 额外的日志输出通道
-	for _, v := range l.extra[level] {
-		_, _ = v.Write(s)
+	// 已关闭或被采样丢弃的日志不再输出
+	if !l.closed.Load() && l.sample(level, pc, msg) {
+		// 配置可能在格式化之前被替换，因此总是记录调用位置
+		if pc == 0 {
+			pc = l.caller()
+		}
+		var fields []Field
+		if ctx != nil {
+			fields = contextFields(ctx)
+		}
+		l.render(level, pc, msg, a, fields)
 	}
 
 	if level == panicLevel {
@@ -74,74 +295,205 @@ func (l *Log) print(level int32, format any, a ...interface{}) {
 	}
 }
 
+// render 使用当前的配置格式化并写入一条日志，fields附加在日志实例的字段之后
+func (l *Log) render(level int32, pc uintptr, msg string, a []interface{}, fields []Field) {
+	l.reloadMu.RLock()
+	defer l.reloadMu.RUnlock()
+	// Close先设置closed再等待读锁释放，持有读锁后需要再次检查
+	if l.closed.Load() {
+		return
+	}
+	f := l.formatter()
+	if f == nil {
+		panic("log: formatter is nil")
+	}
+	// 缓冲区在所有输出写入完成后才归还，避免被并发的日志调用复用
+	buf := getBuffer()
+	opt := l.at(pc)
+	if len(fields) > 0 {
+		opt.fields = append(slices.Clip(opt.fields), fields...)
+	}
+	f.Format(opt, buf, level, msg, a...)
+	l.output(level, *buf, opt.Fields(), l.dedupKey(opt, msg, a))
+	putOption(opt)
+	putBuffer(buf)
+}
+
+// caller 返回调用日志方法的位置，只能在print中直接调用
+func (l *Log) caller() uintptr {
+	var pcs [1]uintptr
+	// runtime.Callers counts itself: caller, print, the exported function, then the user.
+	runtime.Callers(l.shared.Load().CallDepth, pcs[:])
+	return pcs[0]
+}
+
+// With 返回一个携带结构化字段的子日志实例
+// 子实例与父实例共享输出、等级和格式器，keyvals为交替的键值对，也可以直接传入Field
+func (l *Log) With(keyvals ...any) *Log {
+	if len(keyvals) == 0 {
+		return l
+	}
+	return &Log{core: l.core, fields: appendFields(l.fields, keyvals), prefix: l.prefix, lv: l.lv, name: l.name}
+}
+
+// emit 使用指定的配置输出一条日志，调用位置需要通过opt.pc给出
+// 与print不同，它不会因为panic和fatal等级而panic或退出程序
+func (l *Log) emit(opt *Option, level int32, msg string) {
+	if l.closed.Load() {
+		return
+	}
+	l.reloadMu.RLock()
+	defer l.reloadMu.RUnlock()
+	if l.closed.Load() {
+		return
+	}
+	l.emitLocked(opt, level, msg)
+}
+
+// emitLocked 与emit相同，但不检查是否已关闭，调用方需要持有reloadMu
+func (l *Log) emitLocked(opt *Option, level int32, msg string) {
+	f := l.formatter()
+	if f == nil {
+		panic("log: formatter is nil")
+	}
+	buf := getBuffer()
+	f.Format(opt, buf, level, msg)
+	l.output(level, *buf, opt.Fields(), l.dedupKey(opt, msg, nil))
+	putBuffer(buf)
+}
+
 // SetLevel SetLevel
-func (l *Log) SetLevel(level int32) {
-	atomic.StoreInt32(&l.level, level)
+func (l *Log) SetLevel(level Level) {
+	l.namesMu.Lock()
+	defer l.namesMu.Unlock()
+	l.lv.own = int32(level)
+	l.lv.set = true
+	l.lv.refresh()
 }
 
 // Debug Debug
 func (l *Log) Debug(format any, a ...interface{}) {
-	l.print(debugLevel, format, a...)
+	l.print(nil, debugLevel, format, a...)
 }
 
 // Info Info
 func (l *Log) Info(format any, a ...interface{}) {
-	l.print(infoLevel, format, a...)
+	l.print(nil, infoLevel, format, a...)
 }
 
 // Warn Warn
 func (l *Log) Warn(format any, a ...interface{}) {
-	l.print(warnLevel, format, a...)
+	l.print(nil, warnLevel, format, a...)
 }
 
 // Error Error
 func (l *Log) Error(format any, a ...interface{}) {
-	l.print(errorLevel, format, a...)
+	l.print(nil, errorLevel, format, a...)
 }
 
 // Panic Panic
 func (l *Log) Panic(format any, a ...interface{}) {
-	l.print(panicLevel, format, a...)
+	l.print(nil, panicLevel, format, a...)
 }
 
 // Fatal Fatal
 func (l *Log) Fatal(format any, a ...interface{}) {
-	l.print(fatalLevel, format, a...)
+	l.print(nil, fatalLevel, format, a...)
+}
+
+// Debugw 输出带有结构化字段的调试日志
+func (l *Log) Debugw(msg string, keyvals ...any) {
+	l.With(keyvals...).print(nil, debugLevel, msg)
+}
+
+// Infow 输出带有结构化字段的信息日志
+func (l *Log) Infow(msg string, keyvals ...any) {
+	l.With(keyvals...).print(nil, infoLevel, msg)
+}
+
+// Warnw 输出带有结构化字段的警告日志
+func (l *Log) Warnw(msg string, keyvals ...any) {
+	l.With(keyvals...).print(nil, warnLevel, msg)
+}
+
+// Errorw 输出带有结构化字段的错误日志
+func (l *Log) Errorw(msg string, keyvals ...any) {
+	l.With(keyvals...).print(nil, errorLevel, msg)
+}
+
+// Panicw 输出带有结构化字段的日志后panic
+func (l *Log) Panicw(msg string, keyvals ...any) {
+	l.With(keyvals...).print(nil, panicLevel, msg)
 }
 
-// WithPath 设置日志输出路径
-func (l *Log) WithLevel(level string) {
-	l.SetLevel(getLevel(level))
+// Fatalw 输出带有结构化字段的日志后退出程序
+func (l *Log) Fatalw(msg string, keyvals ...any) {
+	l.With(keyvals...).print(nil, fatalLevel, msg)
 }
 
-// 设置日志格式器
-func (l *Log) SetFormatter(level string) {
-	l.SetLevel(getLevel(level))
+// WithLevel 按名称设置日志等级，level的格式见 [ParseLevel]
+func (l *Log) WithLevel(level string) error {
+	lv, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.SetLevel(lv)
+	return nil
 }
 
-// 设置日志输出
+// 设置日志格式器，可以在其它协程输出日志时安全调用
+func (l *Log) SetFormatter(f Formatter) {
+	l.format.Store(&f)
+}
+
+// formatter 返回当前使用的格式器
+func (c *core) formatter() Formatter {
+	if f := c.format.Load(); f != nil {
+		return *f
+	}
+	return nil
+}
+
+// 设置日志输出，可以在其它协程输出日志时安全调用
+// 输出会被串行写入，自带锁的输出可以用 [Concurrent] 声明以避免额外的加锁
 func (l *Log) SetWriter(w io.Writer) {
-	l.writer = w
+	l.update(func(o *outputs) {
+		o.writer = w
+	})
 }
 
 // 为指定等级的日志设置额外的输出
 // 通常用于需要特别关注的紧急日志
-func (l *Log) SetLevelWriter(level string, w ...io.Writer) {
-	lv := getLevel(level)
-	l.extra[lv] = append(l.extra[lv], w...)
+func (l *Log) SetLevelWriter(level string, w ...io.Writer) error {
+	lv, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.update(func(o *outputs) {
+		o.extra[lv] = append(slices.Clip(o.extra[lv]), w...)
+	})
+	return nil
 }
 
 // 设置日志显示格式
 // 需要注意，对于一些自定义的formatter，它并不是绝对生效的
+// flag由同一日志实例派生出的所有实例共享，包括已经创建的With和Named子实例
 func (l *Log) SetFlag(flag int) {
-	l.opt.SetFlags(flag)
+	l.setOption(func(o *Option) { o.Flag = flag })
 }
 
 // 通常我们需要把日志传递给第三方模块使用，并想要标记是第三方模块
 // 那么可以使用此函数获取一个带有指定标记的日志实例
+//
+// Deprecated: 它只会设置flag，与 [Log.SetFlag] 相同；
+// 需要带有标记的子日志实例时使用 [Log.Named]，设置前缀使用 [Log.SetLogPrefix]
 func (l *Log) SetPrefix(flag int) *Log {
-	l.opt.SetFlags(flag)
-
-	// todo: wait do
+	l.SetFlag(flag)
 	return l
 }
+
+// SetLogPrefix 设置日志前缀，与标准库一样原样输出，需要分隔符时应包含在prefix中，例如 "app: "
+// 前缀位于每行的开头，设置了 [Lmsgprefix] 时位于日志内容之前；通过With派生的子实例使用同一个前缀
+func (l *Log) SetLogPrefix(prefix string) {
+	l.prefix.Store(&prefixVal{s: prefix})
+}
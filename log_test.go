@@ -1,9 +1,15 @@
 package log
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestInitLogger(t *testing.T) {
@@ -24,3 +30,198 @@ func TestInitLogger(t *testing.T) {
 	fmt.Println("hello")
 
 }
+
+func TestSetFormatter(t *testing.T) {
+	var b strings.Builder
+	l := New(&b, "", 0)
+
+	f, err := NewFormatter("JSON")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.setOption(func(o *Option) { o.ShowFuncName = false })
+	l.SetFormatter(f)
+	l.Info("x")
+	if got, want := b.String(), `{"level":"info","msg":"x"}`+"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if _, err := NewFormatter("unknown"); err == nil {
+		t.Error("expected error for unknown formatter")
+	}
+	RegisterFormatter("custom", func() Formatter { return &DefaultFormat{} })
+	if _, err := NewFormatter("custom"); err != nil {
+		t.Error(err)
+	}
+}
+
+type closeCounter struct {
+	strings.Builder
+	closed int
+}
+
+func (c *closeCounter) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestClose(t *testing.T) {
+	w := new(closeCounter)
+	l := New(w, "", 0)
+	l.SetLevelWriter("error", w)
+	l.SetLevelWriter("warn", w, os.Stdout)
+
+	l.Info("before")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if w.closed != 1 {
+		t.Errorf("writer closed %d times, want 1", w.closed)
+	}
+	if err := l.Close(); err != ErrClosed {
+		t.Errorf("second Close: got %v, want %v", err, ErrClosed)
+	}
+
+	n := w.Len()
+	l.With("k", "v").Error("after")
+	if w.Len() != n {
+		t.Errorf("closed logger still writes: %q", w.String())
+	}
+}
+
+// closingWriter 并发安全的输出，关闭后的写入返回 os.ErrClosed
+type closingWriter struct {
+	lockedWriter
+	closed   atomic.Bool
+	failures atomic.Int64
+}
+
+func (w *closingWriter) Write(p []byte) (int, error) {
+	if w.closed.Load() {
+		w.failures.Add(1)
+		return 0, os.ErrClosed
+	}
+	return w.lockedWriter.Write(p)
+}
+
+func (w *closingWriter) ConcurrentSafe() {}
+
+func (w *closingWriter) Close() error {
+	w.closed.Store(true)
+	return nil
+}
+
+func TestCloseConcurrentWriter(t *testing.T) {
+	w := new(closingWriter)
+	l := New(w, "", 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				l.Info("x")
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	l.Close()
+	wg.Wait()
+	if n := w.failures.Load(); n != 0 {
+		t.Errorf("%d writes reached the writer after Close", n)
+	}
+}
+
+// lockedWriter 并发安全的输出
+type lockedWriter struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.b.Write(p)
+}
+
+func (w *lockedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.b.String()
+}
+
+func TestConcurrentBuffers(t *testing.T) {
+	const goroutines, lines = 16, 200
+	main, extra := new(lockedWriter), new(lockedWriter)
+	l := New(main, "", 0)
+	l.SetFormatter(&DefaultFormat{})
+	l.SetLevelWriter("info", extra)
+
+	payload := strings.Repeat("x", 512)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < lines; i++ {
+				l.Info("g=%02d i=%03d %s", g, i, payload)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	line := regexp.MustCompile(`^\[info \] g=[0-9]{2} i=[0-9]{3} x{512}$`)
+	for _, w := range []*lockedWriter{main, extra} {
+		out := strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n")
+		if len(out) != goroutines*lines {
+			t.Fatalf("got %d lines, want %d", len(out), goroutines*lines)
+		}
+		for _, s := range out {
+			if !line.MatchString(s) {
+				t.Fatalf("corrupted line %q", s)
+			}
+		}
+	}
+}
+
+func TestSerializedWrites(t *testing.T) {
+	var main, extra bytes.Buffer
+	l := New(&main, "", 0)
+	l.SetFormatter(&DefaultFormat{})
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if g == 0 && i == 50 {
+					l.SetLevelWriter("warn", &extra)
+				}
+				l.Warn("g=%d i=%02d", g, i)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if n := strings.Count(main.String(), "\n"); n != 800 {
+		t.Errorf("got %d lines, want 800", n)
+	}
+	for _, s := range strings.Split(strings.TrimSuffix(extra.String(), "\n"), "\n") {
+		if !regexp.MustCompile(`^\[warn \] g=[0-7] i=[0-9]{2}$`).MatchString(s) {
+			t.Fatalf("corrupted line %q", s)
+		}
+	}
+}
+
+func TestConcurrentWriter(t *testing.T) {
+	w := new(lockedWriter)
+	l := New(Concurrent(w), "", 0)
+	if o := l.out.Load(); !o.safe[infoLevel] {
+		t.Error("writer declared concurrent should skip the lock")
+	}
+	l.SetLevelWriter("info", new(bytes.Buffer))
+	if o := l.out.Load(); o.safe[infoLevel] || !o.safe[warnLevel] {
+		t.Error("plain writer should require the lock")
+	}
+}
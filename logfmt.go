@@ -0,0 +1,54 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// logfmtFormat 以logfmt格式输出日志
+//
+//	ts=2009-01-23T01:23:23+08:00 level=info logger=db msg="query done" caller=d.go:23 rows=3
+type logfmtFormat struct{}
+
+// NewLogfmt creates a Formatter that writes logfmt key=value lines.
+func NewLogfmt() *logfmtFormat {
+	return &logfmtFormat{}
+}
+
+// Format implements log.Formatter.
+func (f *logfmtFormat) Format(lm *Option, buf *[]byte, level int32, format string, args ...interface{}) {
+	now := time.Now()
+
+	if lm.hasTime() {
+		*buf = append(*buf, "ts="...)
+		lm.timestamp(buf, now)
+		*buf = append(*buf, ' ')
+	}
+	*buf = append(*buf, "level="...)
+	*buf = append(*buf, levelNames[level]...)
+	if prefix := lm.Prefix(); prefix != "" {
+		*buf = append(*buf, " logger="...)
+		appendTextValue(buf, prefix)
+	}
+
+	msg := format
+	if len(args) > 0 {
+		msg = fmt.Sprintf(format, args...)
+	}
+	*buf = append(*buf, " msg="...)
+	appendTextValue(buf, msg)
+
+	if lm.Flag&(Lshortfile|Llongfile) != 0 {
+		frame := lm.Frame()
+		file := frame.File
+		if lm.Flag&Lshortfile != 0 {
+			file = shortFile(file)
+		}
+		*buf = append(*buf, " caller="...)
+		appendTextValue(buf, file+":"+strconv.Itoa(frame.Line))
+	}
+
+	appendTextFields(buf, lm.Fields())
+	*buf = append(*buf, '\n')
+}
@@ -0,0 +1,22 @@
+package log
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLogfmtFormat(t *testing.T) {
+	var b strings.Builder
+	l := New(&b, "", Ldate|Ltime|Lshortfile)
+	l.SetFormatter(NewLogfmt())
+	l.SetLogPrefix("db")
+
+	l.With("sql", "select 1", "rows", 3, "empty", "", "eq", "a=b").Error("query failed\nretry %d", 2)
+
+	pattern := `^ts=[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9:]{8}\S* level=error logger=db msg="query failed\\nretry 2" ` +
+		`caller=logfmt_test\.go:[0-9]+ sql="select 1" rows=3 empty="" eq="a=b"\n$`
+	if !regexp.MustCompile(pattern).MatchString(b.String()) {
+		t.Errorf("output %q does not match %q", b.String(), pattern)
+	}
+}
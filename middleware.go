@@ -0,0 +1,245 @@
+package log
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AccessFormat 访问日志的格式
+type AccessFormat int
+
+const (
+	AccessDefault  AccessFormat = iota // 状态码、耗时、客户端地址、方法和路径，JSON/logfmt格式器下输出结构化字段
+	AccessCommon                       // Apache Common Log Format
+	AccessCombined                     // Apache Combined Log Format，在Common的基础上增加Referer和User-Agent
+)
+
+// accessLog HTTP访问日志中间件的配置
+type accessLog struct {
+	l          *Log
+	format     AccessFormat
+	trustProxy bool
+	color      *bool // nil表示根据输出是否为终端自动判断
+	now        func() time.Time
+}
+
+// MiddlewareOption HTTP访问日志中间件的配置
+type MiddlewareOption func(a *accessLog)
+
+// WithAccessFormat 设置访问日志的格式，默认为 [AccessDefault]
+func WithAccessFormat(format AccessFormat) MiddlewareOption {
+	return func(a *accessLog) {
+		a.format = format
+	}
+}
+
+// WithTrustProxy 使用X-Forwarded-For中最左边的地址作为客户端地址
+// 只应在服务部署在可信的反向代理之后时开启
+func WithTrustProxy(trust bool) MiddlewareOption {
+	return func(a *accessLog) {
+		a.trustProxy = trust
+	}
+}
+
+// WithAccessColor 强制开启或关闭状态码和方法的颜色，默认仅在输出为终端时开启
+func WithAccessColor(color bool) MiddlewareOption {
+	return func(a *accessLog) {
+		a.color = &color
+	}
+}
+
+// HTTPMiddleware 返回记录HTTP访问日志的中间件
+// 每个请求结束后输出一条日志，5xx为error等级，4xx为warn等级，其余为info等级
+// 处理请求时发生panic记为500，记录后重新panic
+func HTTPMiddleware(l *Log, options ...MiddlewareOption) func(http.Handler) http.Handler {
+	a := &accessLog{l: l, now: time.Now}
+	for _, op := range options {
+		op(a)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := a.now()
+			rw := &responseWriter{ResponseWriter: w}
+			// 下游可能修改r.URL，提前记录
+			path := r.URL.RequestURI()
+			defer func() {
+				// 处理请求时panic按500记录，之后继续panic，由上层决定如何处理
+				if v := recover(); v != nil {
+					rw.status = http.StatusInternalServerError
+					a.log(r, path, rw, start)
+					panic(v)
+				}
+				a.log(r, path, rw, start)
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// log 输出一条访问日志
+func (a *accessLog) log(r *http.Request, path string, rw *responseWriter, start time.Time) {
+	status := rw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	level := int32(infoLevel)
+	switch {
+	case status >= 500:
+		level = errorLevel
+	case status >= 400:
+		level = warnLevel
+	}
+	if Level(level) < a.l.Level() {
+		return
+	}
+
+	latency := a.now().Sub(start)
+	ip := a.remoteIP(r)
+	opt := a.l.option().clone()
+	// 调用位置总是中间件本身，没有意义
+	opt.Flag &^= Lshortfile | Llongfile
+	opt.ShowFuncName = false
+
+	var msg string
+	switch a.format {
+	case AccessCommon, AccessCombined:
+		msg = a.apacheLine(r, path, status, rw.bytes, ip, start)
+	default:
+		switch a.l.formatter().(type) {
+		case *jsonFormat, *logfmtFormat:
+			opt.fields = append(slices.Clip(opt.fields),
+				F("status", status),
+				F("method", r.Method),
+				F("path", path),
+				F("ip", ip),
+				F("latency", latency.String()),
+				F("bytes", rw.bytes),
+				F("user_agent", r.UserAgent()),
+			)
+			msg = "http request"
+		default:
+			statusText, method := strconv.Itoa(status), r.Method
+			if a.colored() {
+				statusText = ColorByStatus(status) + " " + statusText + " " + reset
+				method = ColorByMethod(method) + " " + method + " " + reset
+			}
+			msg = fmt.Sprintf("%s | %13v | %15s | %s %q | %dB | %q", statusText, latency, ip, method, path, rw.bytes, r.UserAgent())
+		}
+	}
+	a.l.emit(opt, level, msg)
+}
+
+// apacheLine 按Apache Common或Combined格式生成日志内容
+func (a *accessLog) apacheLine(r *http.Request, path string, status int, bytes int64, ip string, start time.Time) string {
+	user := "-"
+	if u, _, ok := r.BasicAuth(); ok && u != "" {
+		user = u
+	}
+	size := "-"
+	if bytes > 0 {
+		size = strconv.FormatInt(bytes, 10)
+	}
+	line := fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s",
+		ip, user, start.Format("02/Jan/2006:15:04:05 -0700"), r.Method, path, r.Proto, status, size)
+	if a.format == AccessCombined {
+		line += fmt.Sprintf(" %q %q", r.Referer(), r.UserAgent())
+	}
+	return line
+}
+
+// remoteIP 返回客户端地址
+func (a *accessLog) remoteIP(r *http.Request) string {
+	if a.trustProxy {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			ip, _, _ := strings.Cut(xff, ",")
+			if ip = strings.TrimSpace(ip); ip != "" {
+				return ip
+			}
+		}
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// colored 判断是否为状态码和方法着色
+func (a *accessLog) colored() bool {
+	if a.color != nil {
+		return *a.color
+	}
+	o := a.l.out.Load()
+	if o == nil {
+		return false
+	}
+	var f *os.File
+	switch w, _ := unwrapWriter(o.writer); w := w.(type) {
+	case *os.File:
+		f = w
+	case *colorWriter:
+		// 默认日志实例的输出
+		f = w.file
+	default:
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// responseWriter 记录状态码和写入的字节数
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("log: ResponseWriter does not implement http.Hijacker")
+	}
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+// Unwrap 供 [http.ResponseController] 访问底层的ResponseWriter
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
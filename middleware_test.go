@@ -0,0 +1,145 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func serveAccess(h http.Handler, method, target string, header map[string]string) {
+	r := httptest.NewRequest(method, target, nil)
+	r.RemoteAddr = "10.0.0.1:5678"
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	h.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+var accessHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/missing" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write([]byte("hello"))
+})
+
+func TestHTTPMiddlewareJSON(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	l.SetFormatter(NewJSON())
+	h := HTTPMiddleware(l, WithTrustProxy(true))(accessHandler)
+
+	serveAccess(h, http.MethodPost, "/missing?q=1", map[string]string{"X-Forwarded-For": "1.2.3.4, 10.0.0.2", "User-Agent": "curl/8"})
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("%v: %s", err, buf.Bytes())
+	}
+	for k, v := range map[string]any{"level": "warn", "msg": "http request", "status": 404.0, "method": "POST", "path": "/missing?q=1", "ip": "1.2.3.4", "user_agent": "curl/8", "bytes": 19.0} {
+		if m[k] != v {
+			t.Errorf("%s = %v, want %v", k, m[k], v)
+		}
+	}
+	if _, ok := m["latency"]; !ok {
+		t.Error("missing latency")
+	}
+}
+
+func TestHTTPMiddlewareText(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	serveAccess(HTTPMiddleware(l)(accessHandler), http.MethodGet, "/", nil)
+	if out := buf.String(); !strings.Contains(out, "200 |") || !strings.Contains(out, `10.0.0.1 | GET "/" | 5B`) || strings.Contains(out, ColorByStatus(200)) {
+		t.Errorf("got %q", out)
+	}
+
+	buf.Reset()
+	serveAccess(HTTPMiddleware(l, WithAccessColor(true))(accessHandler), http.MethodGet, "/", nil)
+	if out := buf.String(); !strings.Contains(out, ColorByStatus(200)+" 200 "+ResetColor()) || !strings.Contains(out, ColorByMethod("GET")+" GET "+ResetColor()) {
+		t.Errorf("got %q", out)
+	}
+
+	buf.Reset()
+	l.SetLevel(warnLevel)
+	serveAccess(HTTPMiddleware(l)(accessHandler), http.MethodGet, "/", nil)
+	if buf.Len() != 0 {
+		t.Errorf("info access log written below level: %q", buf.String())
+	}
+}
+
+func TestHTTPMiddlewareApache(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	start := time.Date(2024, 3, 5, 7, 8, 9, 0, time.FixedZone("", -7*3600))
+	mw := func(format AccessFormat) http.Handler {
+		return HTTPMiddleware(l, WithAccessFormat(format), func(a *accessLog) { a.now = func() time.Time { return start } })(accessHandler)
+	}
+
+	serveAccess(mw(AccessCommon), http.MethodGet, "/a?b=c", map[string]string{"X-Forwarded-For": "1.2.3.4"})
+	want := `10.0.0.1 - - [05/Mar/2024:07:08:09 -0700] "GET /a?b=c HTTP/1.1" 200 5`
+	if out := buf.String(); !strings.HasSuffix(out, want+"\n") {
+		t.Errorf("common: got %q, want suffix %q", out, want)
+	}
+
+	buf.Reset()
+	serveAccess(mw(AccessCombined), http.MethodGet, "/", map[string]string{"Referer": "http://example.com/", "User-Agent": "Mozilla/5.0"})
+	want = `10.0.0.1 - - [05/Mar/2024:07:08:09 -0700] "GET / HTTP/1.1" 200 5 "http://example.com/" "Mozilla/5.0"`
+	if out := buf.String(); !strings.HasSuffix(out, want+"\n") {
+		t.Errorf("combined: got %q, want suffix %q", out, want)
+	}
+}
+
+func TestHTTPMiddlewarePanic(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	l.SetFormatter(NewJSON())
+	h := HTTPMiddleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("boom")
+	}))
+
+	func() {
+		defer func() {
+			if v := recover(); v != "boom" {
+				t.Errorf("recovered %v, want boom", v)
+			}
+		}()
+		serveAccess(h, http.MethodGet, "/", nil)
+	}()
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("%v: %s", err, buf.Bytes())
+	}
+	if m["level"] != "error" || m["status"] != 500.0 {
+		t.Errorf("got %s", buf.Bytes())
+	}
+}
+
+func TestHTTPMiddlewareColorDefaultWriter(t *testing.T) {
+	if _, ok := Default().out.Load().writer.(*colorWriter); !ok {
+		t.Fatalf("default writer is %T", Default().out.Load().writer)
+	}
+	// 使用与默认日志实例相同的输出包装，以字符设备代替终端
+	tty, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer tty.Close()
+	if fi, err := tty.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		t.Skip("null device is not a character device")
+	}
+	l := New(io.Discard, "", 0)
+	l.SetWriter(newColorWriter(tty))
+	if a := (&accessLog{l: l}); !a.colored() {
+		t.Error("access log is not colored on a terminal behind the default color writer")
+	}
+	l.SetWriter(new(bytes.Buffer))
+	if a := (&accessLog{l: l}); a.colored() {
+		t.Error("access log is colored on a buffer")
+	}
+}
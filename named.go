@@ -0,0 +1,147 @@
+package log
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// levelVar 日志等级的层级节点，未单独设置等级的节点继承父节点的等级
+// 除level外的字段由core.namesMu保护
+type levelVar struct {
+	level    atomic.Int32 // 生效的等级，输出日志时只需读取它
+	own      int32        // 单独设置的等级
+	set      bool         // 是否单独设置了等级，根节点总是true
+	parent   *levelVar
+	children []*levelVar
+}
+
+// refresh 重新计算自身及所有子孙节点生效的等级
+func (v *levelVar) refresh() {
+	lv := v.own
+	if !v.set && v.parent != nil {
+		lv = v.parent.level.Load()
+	}
+	v.level.Store(lv)
+	for _, c := range v.children {
+		c.refresh()
+	}
+}
+
+// newLog 创建一个未命名的根日志实例
+func newLog(opt *Option) *Log {
+	c := &core{root: &levelVar{set: true}, names: make(map[string]*Log)}
+	c.shared.Store(opt)
+	l := &Log{core: c, fields: opt.fields, prefix: new(atomic.Pointer[prefixVal]), lv: c.root}
+	if p := opt.Prefix(); p != "" {
+		l.prefix.Store(&prefixVal{s: p, named: opt.named})
+	}
+	return l
+}
+
+// Named 返回名为 父名称.name 的子日志实例，名称通过 [Option.Prefix] 输出
+// 通常我们需要把日志传递给第三方模块使用，并想要标记是第三方模块，那么可以使用此函数获取一个带有指定标记的日志实例
+// 子实例与父实例共享输出、格式器和flag；等级可以按名称单独设置，未设置时继承上一级的等级
+// 同名的日志实例共享等级，name中的点号会创建中间层级
+func (l *Log) Named(name string) *Log {
+	name = strings.Trim(name, ".")
+	if name == "" {
+		return l
+	}
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+
+	l.namesMu.Lock()
+	defer l.namesMu.Unlock()
+	lv := l.root
+	for i := 0; i <= len(name); i++ {
+		if i < len(name) && name[i] != '.' {
+			continue
+		}
+		lv = l.register(name[:i], lv).lv
+	}
+
+	return &Log{core: l.core, fields: l.fields, prefix: namedPrefix(name), lv: lv, name: name}
+}
+
+// register 返回名为name的日志实例，不存在时在parent下创建，需要持有namesMu
+func (l *Log) register(name string, parent *levelVar) *Log {
+	if n, ok := l.names[name]; ok {
+		return n
+	}
+	lv := &levelVar{parent: parent}
+	lv.level.Store(parent.level.Load())
+	parent.children = append(parent.children, lv)
+
+	n := &Log{core: l.core, prefix: namedPrefix(name), lv: lv, name: name}
+	l.names[name] = n
+	return n
+}
+
+// lookup 返回已经通过Named创建的名为 父名称.name 的日志实例，不存在时返回nil
+func (l *Log) lookup(name string) *Log {
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	l.namesMu.Lock()
+	defer l.namesMu.Unlock()
+	return l.names[name]
+}
+
+// namedPrefix 返回以name为前缀的新前缀
+func namedPrefix(name string) *atomic.Pointer[prefixVal] {
+	p := new(atomic.Pointer[prefixVal])
+	p.Store(&prefixVal{s: name, named: true})
+	return p
+}
+
+// Name 返回日志实例的名称，根日志实例的名称为空
+func (l *Log) Name() string {
+	return l.name
+}
+
+// Level 返回日志实例当前生效的等级
+func (l *Log) Level() Level {
+	return Level(l.lv.level.Load())
+}
+
+// ResetLevel 取消单独设置的等级，重新继承上一级的等级，对根日志实例无效
+func (l *Log) ResetLevel() {
+	l.namesMu.Lock()
+	defer l.namesMu.Unlock()
+	if l.lv.parent == nil {
+		return
+	}
+	l.lv.set = false
+	l.lv.refresh()
+}
+
+// LevelSet 返回是否单独设置了等级
+func (l *Log) LevelSet() bool {
+	l.namesMu.Lock()
+	defer l.namesMu.Unlock()
+	return l.lv.set
+}
+
+// Loggers 返回通过Named创建的所有日志实例，按名称排序
+func (l *Log) Loggers() []*Log {
+	l.namesMu.Lock()
+	defer l.namesMu.Unlock()
+	loggers := make([]*Log, 0, len(l.names))
+	for _, n := range l.names {
+		loggers = append(loggers, n)
+	}
+	sort.Slice(loggers, func(i, j int) bool { return loggers[i].name < loggers[j].name })
+	return loggers
+}
+
+// Named 返回默认日志实例的具名子实例
+func Named(name string) *Log {
+	return std.Named(name)
+}
+
+// Loggers 返回默认日志实例下所有具名的日志实例
+func Loggers() []*Log {
+	return std.Loggers()
+}
@@ -0,0 +1,73 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "", 0)
+	l.SetFormatter(&DefaultFormat{})
+	l.SetLevel(infoLevel)
+
+	db := l.Named("app").Named("db")
+	pool := l.Named("app.db.pool")
+	if db.Name() != "app.db" || pool.Name() != "app.db.pool" {
+		t.Fatalf("unexpected names %q %q", db.Name(), pool.Name())
+	}
+
+	db.SetLevel(debugLevel)
+	pool.Debug("inherits debug from app.db")
+	l.Named("app").Debug("hidden, app inherits info from root")
+	pool.SetLevel(errorLevel)
+	pool.Warn("hidden by own level")
+	pool.ResetLevel()
+	pool.With("conn", 3).Debug("inherits again")
+	l.SetLevel(errorLevel)
+	db.Debug("own level is kept")
+	l.Named("app").Info("hidden by root level")
+
+	want := "app.db.pool [debug] inherits debug from app.db\n" +
+		"app.db.pool [debug] inherits again conn=3\n" +
+		"app.db [debug] own level is kept\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+
+	var names []string
+	for _, n := range l.Loggers() {
+		names = append(names, n.Name()+"="+levelNames[n.Level()])
+	}
+	if got := names; len(got) != 3 || got[0] != "app=error" || got[1] != "app.db=debug" || got[2] != "app.db.pool=debug" {
+		t.Errorf("unexpected loggers %v", got)
+	}
+}
+
+func TestNamedMsgPrefix(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "", Lmsgprefix)
+	l.SetFormatter(&DefaultFormat{})
+
+	l.Named("third").Info("x")
+	if got, want := b.String(), "[info ] third x\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLogPrefix(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "app: ", 0)
+	l.SetFormatter(&DefaultFormat{})
+	l.Info("x")
+	l.SetFlag(Lmsgprefix)
+	l.SetLogPrefix("db: ")
+	l.Info("y")
+	if got, want := b.String(), "app: [info ] x\n[info ]db:  y\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if l.SetPrefix(Lshortfile) != l || l.option().Flag != Lshortfile {
+		t.Errorf("SetPrefix(flag) should set the flag, got %d", l.option().Flag)
+	}
+}
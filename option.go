@@ -2,7 +2,6 @@ package log
 
 import (
 	"runtime"
-	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -14,6 +13,9 @@ type Option struct {
 	Flag         int
 	ShowFuncName bool
 	prefix       atomic.Pointer[string]
+	named        bool // 前缀是Named的名称，输出时与其它内容以空格分隔；否则原样输出
+	fields       []Field
+	pc           uintptr // 调用位置已知时（例如来自slog.Record）不再从调用栈查找
 }
 
 // levels
@@ -54,31 +56,17 @@ const (
 var (
 	// adapters    = make(map[string]newLoggerFunc)
 	levelPrefix = [fatalLevel + 1]string{"[debug]", "[info ]", "[warn ]", "[error]", "[panic]", "[fatal]"}
+	levelNames  = [fatalLevel + 1]string{"debug", "info", "warn", "error", "panic", "fatal"}
 )
 
 var bufferPool = sync.Pool{New: func() any { return new([]byte) }}
 
+// Formatter 将一条日志追加到buf中
+// buf和lm由调用方从缓冲池中获取，在所有输出写入完成后才会归还，Format不能保留它们的引用
 type Formatter interface {
 	// Name() string      // 格式器名称
 	// Option(lm *Option) // 用于调整适合此格式器的默认配置
-	Format(lm *Option, level int32, msg string, args ...interface{}) []byte
-}
-
-func getLevel(level string) int32 {
-	switch strings.ToLower(level) {
-	case "debug":
-		return debugLevel
-	case "info":
-		return infoLevel
-	case "warn":
-		return warnLevel
-	case "error":
-		return errorLevel
-	case "fatal":
-		return fatalLevel
-	default:
-		return debugLevel
-	}
+	Format(lm *Option, buf *[]byte, level int32, msg string, args ...interface{})
 }
 
 func getBuffer() *[]byte {
@@ -131,6 +119,21 @@ func (p *Option) CallPath(pc uintptr) (file string, line int) {
 	return
 }
 
+// Frame returns the stack frame of the log call site.
+// [Log] 在调用Format之前已经确定了调用位置；调用位置未知时才从调用栈查找，此时必须在 [Formatter.Format] 中直接调用
+func (p *Option) Frame() runtime.Frame {
+	pcs := [1]uintptr{p.pc}
+	// runtime.Callers counts itself, and Frame sits one level above CallPath.
+	if p.pc == 0 && runtime.Callers(p.CallDepth+1, pcs[:]) == 0 {
+		return runtime.Frame{File: "???"}
+	}
+	f, _ := runtime.CallersFrames(pcs[:]).Next()
+	if f.File == "" {
+		f.File = "???"
+	}
+	return f
+}
+
 // Flags returns the output flags for the logger.
 // The flag bits are [Ldate], [Ltime], and so on.
 func (p *Option) Flags() int {
@@ -143,6 +146,50 @@ func (p *Option) SetFlags(flag int) {
 	p.Flag = flag
 }
 
+// Fields returns the structured fields attached to the logger.
+func (l *Option) Fields() []Field {
+	return l.fields
+}
+
+// clone 复制一份配置，用于派生子日志实例
+func (l *Option) clone() *Option {
+	c := &Option{
+		CallDepth:    l.CallDepth,
+		Level:        l.Level,
+		Flag:         l.Flag,
+		ShowFuncName: l.ShowFuncName,
+		named:        l.named,
+		fields:       l.fields,
+	}
+	if p := l.prefix.Load(); p != nil {
+		c.prefix.Store(p)
+	}
+	return c
+}
+
+var optionPool = sync.Pool{New: func() any { return new(Option) }}
+
+// at 从缓冲池中取出一份带有调用位置pc的配置副本，用完后通过putOption归还
+// 每条日志使用自己的副本，格式器总能拿到用户代码的调用位置，无论中间经过了几层调用
+func (l *Option) at(pc uintptr) *Option {
+	c := optionPool.Get().(*Option)
+	c.CallDepth = l.CallDepth
+	c.Level = l.Level
+	c.Flag = l.Flag
+	c.ShowFuncName = l.ShowFuncName
+	c.named = l.named
+	c.fields = l.fields
+	c.pc = pc
+	c.prefix.Store(l.prefix.Load())
+	return c
+}
+
+func putOption(c *Option) {
+	c.fields = nil
+	c.prefix.Store(nil)
+	optionPool.Put(c)
+}
+
 // Prefix returns the output prefix for the logger.
 func (l *Option) Prefix() string {
 	if p := l.prefix.Load(); p != nil {
@@ -186,18 +233,28 @@ func (l *Option) Time(buf *[]byte, t time.Time) {
 	}
 }
 
+// hasTime 是否需要输出时间
+func (l *Option) hasTime() bool {
+	return l.Flag&(Ldate|Ltime|Lmicroseconds) != 0
+}
+
+// timestamp 以RFC3339格式追加时间，设置Lmicroseconds时精确到微秒
+func (l *Option) timestamp(buf *[]byte, t time.Time) {
+	if l.Flag&LUTC != 0 {
+		t = t.UTC()
+	}
+	layout := time.RFC3339
+	if l.Flag&Lmicroseconds != 0 {
+		layout = "2006-01-02T15:04:05.000000Z07:00"
+	}
+	*buf = t.AppendFormat(*buf, layout)
+}
+
 func (l *Option) File(buf *[]byte) {
 	if l.Flag&(Lshortfile|Llongfile) != 0 {
-		file, line := l.CallPath(0)
+		file, line := l.CallPath(l.pc)
 		if l.Flag&Lshortfile != 0 {
-			short := file
-			for i := len(file) - 1; i > 0; i-- {
-				if file[i] == '/' {
-					short = file[i+1:]
-					break
-				}
-			}
-			file = short
+			file = shortFile(file)
 		}
 		*buf = append(*buf, " ["...)
 		*buf = append(*buf, file...)
@@ -0,0 +1,164 @@
+// Package otel 为日志附加与OpenTelemetry兼容的trace_id、span_id和trace_flags字段
+//
+// 本包不依赖OpenTelemetry SDK：span context可以通过 [ContextWithSpanContext] 放入context，
+// 也可以通过 [SetSpanContextFunc] 从其它追踪库中读取，或者由W3C traceparent请求头解析得到。
+package otel
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/nbcx/log"
+)
+
+// TraceID W3C trace-id
+type TraceID [16]byte
+
+// IsValid 全零的trace-id无效
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+// String 返回小写十六进制表示
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID W3C parent-id
+type SpanID [8]byte
+
+// IsValid 全零的span-id无效
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// String 返回小写十六进制表示
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// TraceFlags W3C trace-flags
+type TraceFlags byte
+
+// FlagsSampled 采样标记
+const FlagsSampled TraceFlags = 0x01
+
+// IsSampled 是否设置了采样标记
+func (f TraceFlags) IsSampled() bool {
+	return f&FlagsSampled != 0
+}
+
+// String 返回两位十六进制表示
+func (f TraceFlags) String() string {
+	return hex.EncodeToString([]byte{byte(f)})
+}
+
+// SpanContext 与OpenTelemetry trace.SpanContext对应的最小集合
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	TraceFlags TraceFlags
+}
+
+// IsValid trace-id和span-id都有效时span context才有效
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+type contextKey struct{}
+
+// ContextWithSpanContext 返回携带sc的context
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, sc)
+}
+
+// SpanContextFunc 从context中读取span context，例如适配OpenTelemetry的trace.SpanContextFromContext
+type SpanContextFunc func(ctx context.Context) (SpanContext, bool)
+
+var lookup atomic.Pointer[SpanContextFunc]
+
+// SetSpanContextFunc 设置从其它追踪库读取span context的函数
+// 通过 [ContextWithSpanContext] 放入的span context优先
+func SetSpanContextFunc(fn SpanContextFunc) {
+	lookup.Store(&fn)
+}
+
+// SpanContextFromContext 返回ctx中有效的span context
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	if sc, ok := ctx.Value(contextKey{}).(SpanContext); ok && sc.IsValid() {
+		return sc, true
+	}
+	if fn := lookup.Load(); fn != nil && *fn != nil {
+		if sc, ok := (*fn)(ctx); ok && sc.IsValid() {
+			return sc, true
+		}
+	}
+	return SpanContext{}, false
+}
+
+// Fields 返回ctx中span context对应的日志字段，可以直接作为 [log.ContextExtractor] 使用
+func Fields(ctx context.Context) []log.Field {
+	sc, ok := SpanContextFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return []log.Field{
+		log.F("trace_id", sc.TraceID.String()),
+		log.F("span_id", sc.SpanID.String()),
+		log.F("trace_flags", sc.TraceFlags.String()),
+	}
+}
+
+var registerOnce sync.Once
+
+// Register 将 [Fields] 注册为日志的context提取器，重复调用只会注册一次
+func Register() {
+	registerOnce.Do(func() {
+		log.RegisterContextExtractor(Fields)
+	})
+}
+
+// ErrInvalidTraceparent traceparent格式不正确
+var ErrInvalidTraceparent = errors.New("otel: invalid traceparent")
+
+// ParseTraceparent 解析W3C traceparent请求头，例如
+//
+//	00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceparent(s string) (SpanContext, error) {
+	var sc SpanContext
+	// version-traceid-parentid-flags
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return sc, ErrInvalidTraceparent
+	}
+	if !isLowerHex(s[0:2]) || !isLowerHex(s[3:35]) || !isLowerHex(s[36:52]) || !isLowerHex(s[53:55]) {
+		return sc, ErrInvalidTraceparent
+	}
+	var version, flags [1]byte
+	_, _ = hex.Decode(version[:], []byte(s[0:2]))
+	// 版本00的长度是固定的，未来的版本可以在末尾追加以'-'分隔的字段
+	if version[0] == 0xff || (version[0] == 0 && len(s) != 55) || (len(s) > 55 && s[55] != '-') {
+		return sc, ErrInvalidTraceparent
+	}
+	_, _ = hex.Decode(sc.TraceID[:], []byte(s[3:35]))
+	_, _ = hex.Decode(sc.SpanID[:], []byte(s[36:52]))
+	_, _ = hex.Decode(flags[:], []byte(s[53:55]))
+	sc.TraceFlags = TraceFlags(flags[0])
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	return sc, nil
+}
+
+// isLowerHex 只包含小写十六进制字符
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
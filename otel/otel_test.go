@@ -0,0 +1,76 @@
+package otel
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nbcx/log"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" || !sc.TraceFlags.IsSampled() {
+		t.Errorf("unexpected span context %+v", sc)
+	}
+	if _, err := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra"); err != nil {
+		t.Errorf("future versions may carry extra fields: %v", err)
+	}
+
+	for _, s := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473--00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceparent(s); err != ErrInvalidTraceparent {
+			t.Errorf("ParseTraceparent(%q): got %v, want %v", s, err, ErrInvalidTraceparent)
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	Register()
+	Register()
+
+	var b bytes.Buffer
+	l := log.New(&b, "", 0)
+	l.SetFormatter(log.NewLogfmt())
+
+	sc := SpanContext{
+		TraceID:    TraceID{0x4b, 0xf9, 15: 0x36},
+		SpanID:     SpanID{7: 0xb7},
+		TraceFlags: FlagsSampled,
+	}
+	l.InfoContext(ContextWithSpanContext(context.Background(), sc), "traced")
+	l.InfoContext(context.Background(), "untraced")
+
+	want := "level=info msg=traced trace_id=4bf90000000000000000000000000036 span_id=00000000000000b7 trace_flags=01\n" +
+		"level=info msg=untraced\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestSetSpanContextFunc(t *testing.T) {
+	type key struct{}
+	SetSpanContextFunc(func(ctx context.Context) (SpanContext, bool) {
+		tp, _ := ctx.Value(key{}).(string)
+		sc, err := ParseTraceparent(tp)
+		return sc, err == nil
+	})
+	defer SetSpanContextFunc(nil)
+
+	ctx := context.WithValue(context.Background(), key{}, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	fields := Fields(ctx)
+	if len(fields) != 3 || !strings.HasPrefix(fields[0].Value.(string), "4bf92f") || fields[2].Value != "00" {
+		t.Errorf("unexpected fields %v", fields)
+	}
+}
@@ -0,0 +1,327 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Sampler 在格式化之前决定一条日志是否输出，被丢弃的日志几乎没有额外开销
+type Sampler interface {
+	// Sample 返回是否输出这条日志。level和msg是日志等级和格式化之前的消息，pc是调用位置
+	// 上一个采样窗口内有日志被丢弃时，suppressed返回被丢弃的条数，Log会据此输出一条汇总
+	Sample(level int32, pc uintptr, msg string) (keep bool, suppressed uint64)
+}
+
+// samplerConfig 采样器的公共配置
+type samplerConfig struct {
+	exempt int32 // 不低于该等级的日志不参与采样
+	now    func() time.Time
+}
+
+// SamplerOption 采样器配置
+type SamplerOption func(c *samplerConfig)
+
+// WithSampleExempt 不低于level的日志总是输出，默认为error
+func WithSampleExempt(level Level) SamplerOption {
+	return func(c *samplerConfig) {
+		c.exempt = int32(level)
+	}
+}
+
+func newSamplerConfig(options []SamplerOption) samplerConfig {
+	c := samplerConfig{exempt: errorLevel, now: time.Now}
+	for _, op := range options {
+		op(&c)
+	}
+	return c
+}
+
+type countKey struct {
+	level int32
+	msg   string
+}
+
+type countWindow struct {
+	start      time.Time
+	pc         uintptr // 窗口内第一条日志的调用位置，用于输出汇总
+	n          uint64
+	suppressed uint64
+}
+
+// sampleSummary 一个结束的采样窗口内被丢弃的日志
+type sampleSummary struct {
+	level      int32
+	pc         uintptr
+	msg        string
+	suppressed uint64
+}
+
+// windowFlusher 由按窗口汇总的采样器实现，窗口结束后即使没有新的日志，Log也会通过它输出汇总
+type windowFlusher interface {
+	// flush 淘汰已经结束的窗口，返回其中被丢弃日志的汇总，pending表示仍有未结束的窗口丢弃了日志
+	// all为true时不论窗口是否结束，返回所有被丢弃日志的汇总，用于Close
+	flush(all bool) (summaries []sampleSummary, pending bool)
+	window() time.Duration
+}
+
+// countSampler 每个采样窗口内，同一等级同一消息先输出first条，之后每thereafter条输出一条
+type countSampler struct {
+	samplerConfig
+	interval   time.Duration
+	first      uint64
+	thereafter uint64
+
+	mu      sync.Mutex
+	windows map[countKey]*countWindow
+	swept   time.Time // 上一次淘汰结束的窗口的时间
+}
+
+// NewCountSampler 创建按消息计数的采样器
+// 每个interval内，同一等级同一消息的日志先输出first条，之后每thereafter条输出一条，thereafter为0时全部丢弃
+// 窗口结束后输出被丢弃日志的汇总，结束的窗口随即被淘汰
+func NewCountSampler(interval time.Duration, first, thereafter int, options ...SamplerOption) Sampler {
+	return &countSampler{
+		samplerConfig: newSamplerConfig(options),
+		interval:      interval,
+		first:         uint64(first),
+		thereafter:    uint64(thereafter),
+		windows:       make(map[countKey]*countWindow),
+	}
+}
+
+// Sample implements Sampler.
+func (s *countSampler) Sample(level int32, pc uintptr, msg string) (bool, uint64) {
+	if level >= s.exempt {
+		return true, 0
+	}
+	now := s.now()
+	key := countKey{level: level, msg: msg}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+	w := s.windows[key]
+	if w == nil {
+		w = &countWindow{start: now, pc: pc}
+		s.windows[key] = w
+	}
+	var suppressed uint64
+	if now.Sub(w.start) >= s.interval {
+		suppressed = w.suppressed
+		*w = countWindow{start: now, pc: pc}
+	}
+	w.n++
+	if w.n <= s.first || (s.thereafter > 0 && (w.n-s.first)%s.thereafter == 0) {
+		return true, suppressed
+	}
+	w.suppressed++
+	return false, suppressed
+}
+
+// sweep 每个interval淘汰一次结束且没有丢弃日志的窗口，丢弃了日志的窗口留给flush汇报，需要持有mu
+func (s *countSampler) sweep(now time.Time) {
+	if now.Sub(s.swept) < s.interval {
+		return
+	}
+	s.swept = now
+	for key, w := range s.windows {
+		if w.suppressed == 0 && now.Sub(w.start) >= s.interval {
+			delete(s.windows, key)
+		}
+	}
+}
+
+// flush implements windowFlusher.
+func (s *countSampler) flush(all bool) ([]sampleSummary, bool) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var summaries []sampleSummary
+	pending := false
+	for key, w := range s.windows {
+		if !all && now.Sub(w.start) < s.interval {
+			pending = pending || w.suppressed > 0
+			continue
+		}
+		if w.suppressed > 0 {
+			summaries = append(summaries, sampleSummary{level: key.level, pc: w.pc, msg: key.msg, suppressed: w.suppressed})
+		}
+		delete(s.windows, key)
+	}
+	return summaries, pending
+}
+
+// window implements windowFlusher.
+func (s *countSampler) window() time.Duration {
+	return s.interval
+}
+
+type bucket struct {
+	tokens     float64
+	last       time.Time
+	suppressed uint64
+	level      int32 // 最后一条被丢弃日志的等级和消息，用于输出汇总
+	msg        string
+}
+
+// rateSampler 按调用位置限速的令牌桶
+type rateSampler struct {
+	samplerConfig
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[uintptr]*bucket
+}
+
+// NewRateSampler 创建按调用位置限速的采样器，每个调用位置每秒最多输出rate条，允许burst条的突发
+// 令牌恢复后输出被丢弃日志的汇总，长时间没有日志的调用位置随即被淘汰
+func NewRateSampler(rate float64, burst int, options ...SamplerOption) Sampler {
+	return &rateSampler{
+		samplerConfig: newSamplerConfig(options),
+		rate:          rate,
+		burst:         float64(burst),
+		buckets:       make(map[uintptr]*bucket),
+	}
+}
+
+// Sample implements Sampler.
+func (s *rateSampler) Sample(level int32, pc uintptr, msg string) (bool, uint64) {
+	if level >= s.exempt {
+		return true, 0
+	}
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.buckets[pc]
+	if b == nil {
+		b = &bucket{tokens: s.burst, last: now}
+		s.buckets[pc] = b
+	}
+	s.refill(b, now)
+	if b.tokens < 1 {
+		b.suppressed++
+		b.level, b.msg = level, msg
+		return false, 0
+	}
+	// 令牌恢复意味着限速窗口结束，汇报期间丢弃的日志
+	b.tokens--
+	suppressed := b.suppressed
+	b.suppressed = 0
+	return true, suppressed
+}
+
+// refill 按经过的时间补充令牌，需要持有mu
+func (s *rateSampler) refill(b *bucket, now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * s.rate
+	if b.tokens > s.burst {
+		b.tokens = s.burst
+	}
+	b.last = now
+}
+
+// flush implements windowFlusher. 令牌恢复的调用位置输出汇总，令牌已满且没有丢弃日志的调用位置被淘汰
+func (s *rateSampler) flush(all bool) ([]sampleSummary, bool) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var summaries []sampleSummary
+	pending := false
+	for pc, b := range s.buckets {
+		s.refill(b, now)
+		switch {
+		case b.suppressed > 0 && (all || b.tokens >= 1):
+			summaries = append(summaries, sampleSummary{level: b.level, pc: pc, msg: b.msg, suppressed: b.suppressed})
+			b.suppressed = 0
+		case b.suppressed > 0:
+			// rate为0时令牌不会恢复，只在Close时汇报
+			pending = pending || s.rate > 0
+		case b.tokens >= s.burst:
+			delete(s.buckets, pc)
+		}
+	}
+	return summaries, pending
+}
+
+// window implements windowFlusher. 恢复一个令牌所需的时间
+func (s *rateSampler) window() time.Duration {
+	if s.rate <= 0 {
+		return time.Second
+	}
+	return time.Duration(float64(time.Second) / s.rate)
+}
+
+// SetSampler 设置采样器，nil表示不采样
+// 内置的采样器在窗口结束或令牌恢复后由后台定时器输出汇总，[Log.Close] 时输出所有未汇报的汇总
+func (l *Log) SetSampler(s Sampler) {
+	if s == nil {
+		l.sampler.Store(nil)
+		return
+	}
+	l.sampler.Store(&s)
+}
+
+// sample 返回调用位置pc上的日志是否需要输出，必要时输出被丢弃日志的汇总
+func (l *Log) sample(level int32, pc uintptr, msg string) bool {
+	p := l.sampler.Load()
+	if p == nil {
+		return true
+	}
+	keep, suppressed := (*p).Sample(level, pc, msg)
+	if suppressed > 0 {
+		l.emit(l.summary(sampleSummary{level: level, pc: pc, msg: msg, suppressed: suppressed}))
+	}
+	if f, ok := (*p).(windowFlusher); ok && !keep {
+		l.scheduleFlush(p, f)
+	}
+	return keep
+}
+
+// summary 返回输出被丢弃日志汇总所需的配置、等级和内容
+func (l *Log) summary(s sampleSummary) (*Option, int32, string) {
+	opt := l.option()
+	opt.pc = s.pc
+	return opt, s.level, fmt.Sprintf("suppressed %d similar messages: %s", s.suppressed, s.msg)
+}
+
+// scheduleFlush 有日志被丢弃时启动定时器，窗口结束后输出汇总
+func (l *Log) scheduleFlush(p *Sampler, f windowFlusher) {
+	if l.sampleArmed.CompareAndSwap(false, true) {
+		time.AfterFunc(f.window(), func() {
+			l.sampleArmed.Store(false)
+			l.flushSamples(p, f)
+		})
+	}
+}
+
+// flushSamples 输出已结束窗口的汇总，仍有窗口丢弃了日志时继续等待
+func (l *Log) flushSamples(p *Sampler, f windowFlusher) {
+	if l.closed.Load() || l.sampler.Load() != p {
+		return
+	}
+	summaries, pending := f.flush(false)
+	for _, s := range summaries {
+		l.emit(l.summary(s))
+	}
+	if pending {
+		l.scheduleFlush(p, f)
+	}
+}
+
+// flushAllSamples 输出所有未汇报的汇总，只在Close中持有reloadMu的写锁时调用
+func (l *Log) flushAllSamples() {
+	p := l.sampler.Load()
+	if p == nil {
+		return
+	}
+	f, ok := (*p).(windowFlusher)
+	if !ok {
+		return
+	}
+	summaries, _ := f.flush(true)
+	for _, s := range summaries {
+		l.emitLocked(l.summary(s))
+	}
+}
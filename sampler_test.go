@@ -0,0 +1,156 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCountSampler(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "", 0)
+	l.SetFormatter(&DefaultFormat{})
+
+	now := time.Unix(0, 0)
+	s := NewCountSampler(time.Second, 2, 3).(*countSampler)
+	s.now = func() time.Time { return now }
+	l.SetSampler(s)
+
+	for i := 0; i < 10; i++ {
+		l.Warn("hot %d", i)
+	}
+	l.Error("exempt")
+	l.Error("exempt")
+	now = now.Add(time.Second)
+	l.Warn("hot %d", 10)
+
+	want := "[warn ] hot 0\n[warn ] hot 1\n[warn ] hot 4\n[warn ] hot 7\n" +
+		"[error] exempt\n[error] exempt\n" +
+		"[warn ] suppressed 6 similar messages: hot %d\n[warn ] hot 10\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestRateSampler(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "", Lshortfile)
+	l.SetFormatter(NewLogfmt())
+
+	now := time.Unix(0, 0)
+	s := NewRateSampler(1, 2, WithSampleExempt(LevelFatal)).(*rateSampler)
+	s.now = func() time.Time { return now }
+	l.SetSampler(s)
+
+	for i := 0; i < 6; i++ {
+		if i == 5 {
+			l.Error("other call site")
+			now = now.Add(time.Second)
+		}
+		l.Error("loop %d", i)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 5 || lines[2] != `level=error msg="other call site" caller=sampler_test.go:48` {
+		t.Fatalf("got %d lines: %q", len(lines), b.String())
+	}
+	if !strings.HasPrefix(lines[3], `level=error msg="suppressed 3 similar messages: loop %d" caller=sampler_test.go:`) {
+		t.Errorf("unexpected summary %q", lines[3])
+	}
+}
+
+func TestCountSamplerFlush(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "", 0)
+	l.SetFormatter(&DefaultFormat{})
+
+	now := time.Unix(0, 0)
+	s := NewCountSampler(time.Second, 1, 0).(*countSampler)
+	s.now = func() time.Time { return now }
+	l.SetSampler(s)
+	p := l.sampler.Load()
+
+	// 循环停止后，窗口结束时仍然输出汇总
+	for i := 0; i < 3; i++ {
+		l.Warn("hot")
+	}
+	l.Info("once")
+	l.flushSamples(p, s)
+	now = now.Add(time.Second)
+	l.flushSamples(p, s)
+
+	want := "[warn ] hot\n[info ] once\n[warn ] suppressed 2 similar messages: hot\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+	if len(s.windows) != 0 {
+		t.Errorf("%d windows left after flush", len(s.windows))
+	}
+
+	// 没有丢弃日志的窗口在结束后被淘汰
+	for i := 0; i < 100; i++ {
+		l.Info(strings.Repeat("x", i))
+	}
+	now = now.Add(time.Second)
+	l.Info("later")
+	if len(s.windows) != 1 {
+		t.Errorf("%d windows left, want 1", len(s.windows))
+	}
+}
+
+func TestCountSamplerTimer(t *testing.T) {
+	b := new(lockedWriter)
+	l := New(b, "", 0)
+	l.SetFormatter(&DefaultFormat{})
+	l.SetSampler(NewCountSampler(10*time.Millisecond, 1, 0))
+
+	l.Warn("hot")
+	l.Warn("hot")
+	waitFor(t, func() bool { return strings.Contains(b.String(), "suppressed 1 similar messages: hot") })
+}
+
+func TestRateSamplerFlush(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "", 0)
+	l.SetFormatter(&DefaultFormat{})
+
+	now := time.Unix(0, 0)
+	s := NewRateSampler(1, 1).(*rateSampler)
+	s.now = func() time.Time { return now }
+	l.SetSampler(s)
+	p := l.sampler.Load()
+
+	// 调用位置停止输出后，令牌恢复时仍然输出汇总
+	for i := 0; i < 3; i++ {
+		l.Warn("quiet %d", i)
+	}
+	l.flushSamples(p, s)
+	now = now.Add(time.Second)
+	l.flushSamples(p, s)
+	now = now.Add(time.Second)
+	l.flushSamples(p, s)
+
+	want := "[warn ] quiet 0\n[warn ] suppressed 2 similar messages: quiet %d\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+	if len(s.buckets) != 0 {
+		t.Errorf("%d buckets left after flush", len(s.buckets))
+	}
+}
+
+func TestSamplerClose(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "", 0)
+	l.SetFormatter(&DefaultFormat{})
+	l.SetSampler(NewCountSampler(time.Hour, 1, 0))
+
+	l.Warn("hot")
+	l.Warn("hot")
+	l.Close()
+	want := "[warn ] hot\n[warn ] suppressed 1 similar messages: hot\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
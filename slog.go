@@ -0,0 +1,195 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"slices"
+	"time"
+)
+
+// slogLevel 将slog的等级映射为日志等级
+func slogLevel(level slog.Level) int32 {
+	switch {
+	case level < slog.LevelInfo:
+		return debugLevel
+	case level < slog.LevelWarn:
+		return infoLevel
+	case level < slog.LevelError:
+		return warnLevel
+	case level < slog.LevelError+4:
+		return errorLevel
+	case level < slog.LevelError+8:
+		return panicLevel
+	default:
+		return fatalLevel
+	}
+}
+
+// toSlogLevel 将日志等级映射为slog的等级
+func toSlogLevel(level int32) slog.Level {
+	switch level {
+	case debugLevel:
+		return slog.LevelDebug
+	case infoLevel:
+		return slog.LevelInfo
+	case warnLevel:
+		return slog.LevelWarn
+	case errorLevel:
+		return slog.LevelError
+	case panicLevel:
+		return slog.LevelError + 4
+	default:
+		return slog.LevelError + 8
+	}
+}
+
+// slogHandler 使用Log输出的slog.Handler
+type slogHandler struct {
+	l      *Log
+	fields []Field // WithAttrs添加的字段，位于l自身的字段之后
+	group  string  // WithGroup累积的键前缀，例如 "req."
+}
+
+// NewSlogHandler 返回一个通过l输出的 [slog.Handler]
+// slog的属性会作为结构化字段交给l当前的格式器，分组以点号连接到键名上
+// 高于slog.LevelError的记录只会以panic或fatal等级输出，不会panic或退出程序
+func NewSlogHandler(l *Log) slog.Handler {
+	return &slogHandler{l: l}
+}
+
+// Enabled implements slog.Handler.
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return slogLevel(level) >= h.l.lv.level.Load() && !h.l.closed.Load()
+}
+
+// Handle implements slog.Handler.
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	// 每条记录都使用l当前的配置，与l自身的日志方法保持一致
+	opt := h.l.option()
+	opt.pc = r.PC
+	cf := contextFields(ctx)
+	if len(h.fields) > 0 || r.NumAttrs() > 0 || len(cf) > 0 {
+		fields := make([]Field, 0, len(opt.fields)+len(h.fields)+len(cf)+r.NumAttrs())
+		fields = append(fields, opt.fields...)
+		fields = append(fields, h.fields...)
+		fields = append(fields, cf...)
+		r.Attrs(func(a slog.Attr) bool {
+			fields = appendAttr(fields, h.group, a)
+			return true
+		})
+		opt.fields = fields
+	}
+	h.l.emit(opt, slogLevel(r.Level), r.Message)
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	fields := slices.Clip(h.fields)
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.group, a)
+	}
+	return &slogHandler{l: h.l, fields: fields, group: h.group}
+}
+
+// WithGroup implements slog.Handler.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{l: h.l, fields: h.fields, group: h.group + name + "."}
+}
+
+// appendAttr 将slog属性展开为字段，分组内的属性键名以点号连接
+func appendAttr(fields []Field, prefix string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		if len(group) == 0 {
+			return fields
+		}
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range group {
+			fields = appendAttr(fields, prefix, ga)
+		}
+		return fields
+	}
+	return append(fields, Field{Key: prefix + a.Key, Value: a.Value.Any()})
+}
+
+// slogLogger 通过slog.Handler输出的Logger
+type slogLogger struct {
+	h slog.Handler
+}
+
+// FromSlog 返回一个通过h输出的 [Logger]
+func FromSlog(h slog.Handler) Logger {
+	return &slogLogger{h: h}
+}
+
+func (s *slogLogger) log(level int32, format any, a []interface{}) {
+	ctx := context.Background()
+	lv := toSlogLevel(level)
+	enabled := s.h.Enabled(ctx, lv)
+	if !enabled && level < panicLevel {
+		return
+	}
+
+	msg := formatPattern(format, a)
+	if len(a) > 0 {
+		msg = fmt.Sprintf(msg, a...)
+	}
+	if enabled {
+		var pcs [1]uintptr
+		runtime.Callers(3, pcs[:]) // skip [runtime.Callers, log, Debug/Info/...]
+		_ = s.h.Handle(ctx, slog.NewRecord(time.Now(), lv, msg, pcs[0]))
+	}
+
+	if level == panicLevel {
+		panic(msg)
+	}
+	if level == fatalLevel {
+		os.Exit(1)
+	}
+}
+
+// Debug Debug
+func (s *slogLogger) Debug(format any, a ...interface{}) {
+	s.log(debugLevel, format, a)
+}
+
+// Info Info
+func (s *slogLogger) Info(format any, a ...interface{}) {
+	s.log(infoLevel, format, a)
+}
+
+// Warn Warn
+func (s *slogLogger) Warn(format any, a ...interface{}) {
+	s.log(warnLevel, format, a)
+}
+
+// Error Error
+func (s *slogLogger) Error(format any, a ...interface{}) {
+	s.log(errorLevel, format, a)
+}
+
+// Panic Panic
+func (s *slogLogger) Panic(format any, a ...interface{}) {
+	s.log(panicLevel, format, a)
+}
+
+// Fatal Fatal
+func (s *slogLogger) Fatal(format any, a ...interface{}) {
+	s.log(fatalLevel, format, a)
+}
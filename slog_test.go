@@ -0,0 +1,77 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "", Lshortfile)
+	l.SetFormatter(NewJSON())
+	l.SetLevel(infoLevel)
+
+	logger := slog.New(NewSlogHandler(l)).With("app", "demo").WithGroup("req")
+	logger.Debug("hidden")
+	logger.Info("handled", "id", 7, slog.Group("user", "name", "bob"), slog.Group("empty"))
+
+	var m map[string]any
+	if err := json.Unmarshal(b.Bytes(), &m); err != nil {
+		t.Fatalf("invalid json %q: %v", b.String(), err)
+	}
+	want := map[string]any{"level": "info", "msg": "handled", "app": "demo", "req.id": float64(7), "req.user.name": "bob"}
+	for k, v := range want {
+		if m[k] != v {
+			t.Errorf("%s: got %v, want %v", k, m[k], v)
+		}
+	}
+	if c, _ := m["caller"].(string); !strings.HasPrefix(c, "slog_test.go:") {
+		t.Errorf("unexpected caller %q", c)
+	}
+	if _, ok := m["req.empty"]; ok {
+		t.Error("empty group should be omitted")
+	}
+}
+
+func TestFromSlog(t *testing.T) {
+	var b bytes.Buffer
+	h := slog.NewTextHandler(&b, &slog.HandlerOptions{
+		AddSource: true,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			if a.Key == slog.SourceKey {
+				src := a.Value.Any().(*slog.Source)
+				return slog.String(a.Key, shortFile(src.File))
+			}
+			return a
+		},
+	})
+
+	var logger Logger = FromSlog(h)
+	logger.Debug("hidden")
+	logger.Warn("disk %d%%", 91)
+
+	if got, want := b.String(), "level=WARN source=slog_test.go msg=\"disk 91%\"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSlogHandlerFollowsLog(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "", 0)
+	l.SetFormatter(NewLogfmt())
+	logger := slog.New(NewSlogHandler(l.With("k", 1)))
+
+	// 创建handler之后修改的配置同样作用于slog的记录
+	l.SetFlag(Lshortfile)
+	l.SetLogPrefix("app")
+	logger.Info("x")
+	if got := b.String(); !strings.HasPrefix(got, "level=info logger=app msg=x caller=slog_test.go:") || !strings.HasSuffix(got, " k=1\n") {
+		t.Errorf("got %q", got)
+	}
+}
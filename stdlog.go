@@ -0,0 +1,73 @@
+package log
+
+import (
+	stdlog "log"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// stdWriterFunc stdWriter方法名的前缀，用于在调用栈中跳过它
+var stdWriterFunc = reflect.TypeOf(Log{}).PkgPath() + ".(*stdWriter)."
+
+// stdWriter 将标准库log的输出转为指定等级的日志
+type stdWriter struct {
+	l     *Log
+	level int32
+}
+
+// Write implements io.Writer. 每次调用对应标准库的一条日志
+func (w *stdWriter) Write(p []byte) (int, error) {
+	if w.level < w.l.lv.level.Load() {
+		return len(p), nil
+	}
+	opt := w.l.option().clone()
+	opt.pc = stdCaller()
+	msg := strings.TrimSuffix(string(p), "\n")
+	w.l.emit(opt, w.level, msg)
+	return len(p), nil
+}
+
+// stdCaller 返回调用标准库log的位置，跳过标准库log和stdWriter的栈帧
+func stdCaller() uintptr {
+	var pcs [16]uintptr
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "log.") && !strings.HasPrefix(f.Function, stdWriterFunc) {
+			return f.PC + 1 // CallPath和Frame期望的是runtime.Callers返回的地址
+		}
+		if !more {
+			return 0
+		}
+	}
+}
+
+// RedirectStdLog 将标准库log的默认输出重定向到l，所有日志以level等级输出，level的格式见 [ParseLevel]
+// 返回的restore用于恢复标准库log原来的输出、flag和前缀；level无效时返回错误，不做任何修改
+func RedirectStdLog(l *Log, level string) (restore func(), err error) {
+	lv, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	out, flags, prefix := stdlog.Writer(), stdlog.Flags(), stdlog.Prefix()
+	stdlog.SetFlags(0)
+	stdlog.SetPrefix("")
+	stdlog.SetOutput(&stdWriter{l: l, level: int32(lv)})
+	return func() {
+		stdlog.SetOutput(out)
+		stdlog.SetFlags(flags)
+		stdlog.SetPrefix(prefix)
+	}, nil
+}
+
+// StdLogger 返回一个以level等级输出到l的标准库 *log.Logger，level的格式见 [ParseLevel]
+// 用于 http.Server.ErrorLog 等只接受 *log.Logger 的地方
+func (l *Log) StdLogger(level string) (*stdlog.Logger, error) {
+	lv, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	return stdlog.New(&stdWriter{l: l, level: int32(lv)}, "", 0), nil
+}
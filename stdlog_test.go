@@ -0,0 +1,59 @@
+package log
+
+import (
+	"bytes"
+	stdlog "log"
+	"regexp"
+	"testing"
+)
+
+func TestRedirectStdLog(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "", Lshortfile)
+	l.SetFormatter(&DefaultFormat{})
+
+	if _, err := RedirectStdLog(l, "loud"); err == nil {
+		t.Fatal("RedirectStdLog accepted an unknown level")
+	}
+	restore, err := RedirectStdLog(l, "warn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdlog.Printf("from %s", "stdlib")
+	stdlog.Println("second")
+	restore()
+	stdlog.SetOutput(&bytes.Buffer{})
+	stdlog.Print("not redirected")
+	restore()
+
+	pattern := `^\[warn \] \[stdlog_test\.go:[0-9]+\] from stdlib\n\[warn \] \[stdlog_test\.go:[0-9]+\] second\n$`
+	if !regexp.MustCompile(pattern).Match(b.Bytes()) {
+		t.Errorf("output %q does not match %q", b.String(), pattern)
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "", Lshortfile)
+	l.SetFormatter(&DefaultFormat{})
+	l.SetLevel(infoLevel)
+
+	debug, err := l.StdLogger("debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	debug.Print("hidden")
+	errLog, err := l.StdLogger("err")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errLog.Printf("code %d", 500)
+	if _, err := l.StdLogger("eror"); err == nil {
+		t.Error("StdLogger accepted an unknown level")
+	}
+
+	pattern := `^\[error\] \[stdlog_test\.go:[0-9]+\] code 500\n$`
+	if !regexp.MustCompile(pattern).Match(b.Bytes()) {
+		t.Errorf("output %q does not match %q", b.String(), pattern)
+	}
+}
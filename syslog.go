@@ -0,0 +1,396 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Facility syslog的facility
+type Facility int
+
+const (
+	FacilityKern Facility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLPR
+	FacilityNews
+	FacilityUUCP
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFTP
+	_
+	_
+	_
+	_
+	FacilityLocal0
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// SyslogFormat syslog消息的格式
+type SyslogFormat int
+
+const (
+	RFC5424 SyslogFormat = iota // 带有结构化数据的新格式
+	RFC3164                     // BSD syslog格式，本地syslog守护进程普遍支持
+)
+
+// syslogSeverity 日志等级对应的syslog severity
+var syslogSeverity = [fatalLevel + 1]int{
+	debugLevel: 7, // debug
+	infoLevel:  6, // informational
+	warnLevel:  4, // warning
+	errorLevel: 3, // err
+	panicLevel: 2, // crit
+	fatalLevel: 1, // alert
+}
+
+// syslogSockets 本地syslog守护进程常见的socket路径
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogWriter 将日志发送给syslog服务，实现了 [FieldWriter]，日志的结构化字段以RFC 5424 structured data发送
+// 写入的内容作为syslog的MSG部分，通常搭配不输出时间和等级的格式器使用，例如flag为0的logfmt
+// 写入失败时会重新连接并重试一次，仍然失败时返回错误，下一次写入时再次连接
+type SyslogWriter struct {
+	network  string
+	addr     string
+	format   SyslogFormat
+	facility Facility
+	hostname string
+	appName  string
+	procID   string
+	msgID    string
+	sdID     string
+	octet    bool // 流式连接是否使用octet-counting分帧，否则以换行分隔
+	local    bool
+	now      func() time.Time
+
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool
+}
+
+// SyslogOption syslog输出的配置
+type SyslogOption func(s *SyslogWriter)
+
+// WithSyslogFormat 设置消息格式，默认连接本地syslog时为 [RFC3164]，其余为 [RFC5424]
+func WithSyslogFormat(format SyslogFormat) SyslogOption {
+	return func(s *SyslogWriter) {
+		s.format = format
+	}
+}
+
+// WithFacility 设置facility，默认为 [FacilityUser]
+func WithFacility(facility Facility) SyslogOption {
+	return func(s *SyslogWriter) {
+		s.facility = facility
+	}
+}
+
+// WithHostname 设置主机名，默认为 [os.Hostname]
+func WithHostname(hostname string) SyslogOption {
+	return func(s *SyslogWriter) {
+		s.hostname = hostname
+	}
+}
+
+// WithAppName 设置应用名称（RFC 3164中的TAG），默认为程序文件名
+func WithAppName(name string) SyslogOption {
+	return func(s *SyslogWriter) {
+		s.appName = name
+	}
+}
+
+// WithProcID 设置进程标识，默认为进程号
+func WithProcID(procID string) SyslogOption {
+	return func(s *SyslogWriter) {
+		s.procID = procID
+	}
+}
+
+// WithMsgID 设置RFC 5424的MSGID，默认为空
+func WithMsgID(msgID string) SyslogOption {
+	return func(s *SyslogWriter) {
+		s.msgID = msgID
+	}
+}
+
+// WithStructuredDataID 设置结构化字段使用的SD-ID，默认为 "fields@32473"
+// 32473是RFC 5612保留给文档示例的企业编号，正式使用时应换成自己的编号
+func WithStructuredDataID(id string) SyslogOption {
+	return func(s *SyslogWriter) {
+		s.sdID = id
+	}
+}
+
+// WithOctetCounting 设置流式连接（tcp、unix）是否使用RFC 6587的octet-counting分帧
+// 默认tcp使用，unix不使用；不使用时每条消息以换行结尾
+func WithOctetCounting(on bool) SyslogOption {
+	return func(s *SyslogWriter) {
+		s.octet = on
+	}
+}
+
+// NewSyslogWriter 连接syslog服务，network为 udp、tcp、unix 或 unixgram
+// network和addr都为空时连接本地syslog守护进程，依次尝试 /dev/log、/var/run/syslog 和 /var/run/log
+func NewSyslogWriter(network, addr string, options ...SyslogOption) (*SyslogWriter, error) {
+	s := &SyslogWriter{
+		network:  network,
+		addr:     addr,
+		facility: FacilityUser,
+		appName:  filepath.Base(os.Args[0]),
+		procID:   strconv.Itoa(os.Getpid()),
+		sdID:     "fields@32473",
+		local:    network == "" && addr == "",
+		now:      time.Now,
+	}
+	s.hostname, _ = os.Hostname()
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		s.octet = true
+	}
+	if s.local {
+		s.format = RFC3164
+	}
+	for _, op := range options {
+		op(s)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// connect 建立连接，需要持有mu
+func (s *SyslogWriter) connect() error {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	if !s.local {
+		conn, err := net.Dial(s.network, s.addr)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+		return nil
+	}
+	for _, path := range syslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := net.Dial(network, path); err == nil {
+				s.conn = conn
+				return nil
+			}
+		}
+	}
+	return errors.New("log: unix syslog delivery error")
+}
+
+// Write implements io.Writer. 以info等级发送
+func (s *SyslogWriter) Write(p []byte) (int, error) {
+	return s.WriteFields(infoLevel, p, nil)
+}
+
+// WriteLevel implements LevelWriter.
+func (s *SyslogWriter) WriteLevel(level int32, p []byte) (int, error) {
+	return s.WriteFields(level, p, nil)
+}
+
+// WriteFields implements FieldWriter.
+func (s *SyslogWriter) WriteFields(level int32, p []byte, fields []Field) (int, error) {
+	msg := s.message(level, bytes.TrimRight(p, "\r\n"), fields)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, os.ErrClosed
+	}
+	if s.conn != nil {
+		if err := s.send(msg); err == nil {
+			return len(p), nil
+		}
+	}
+	if err := s.connect(); err != nil {
+		return 0, err
+	}
+	if err := s.send(msg); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// send 按连接类型分帧后发送，需要持有mu
+func (s *SyslogWriter) send(msg []byte) error {
+	if _, ok := s.conn.(net.PacketConn); !ok {
+		if s.octet {
+			msg = append(strconv.AppendInt(nil, int64(len(msg)), 10), append([]byte{' '}, msg...)...)
+		} else {
+			msg = append(msg, '\n')
+		}
+	}
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+// message 生成一条syslog消息，不含分帧
+func (s *SyslogWriter) message(level int32, msg []byte, fields []Field) []byte {
+	severity := syslogSeverity[fatalLevel]
+	if level >= debugLevel && level <= fatalLevel {
+		severity = syslogSeverity[level]
+	}
+	buf := make([]byte, 0, len(msg)+128)
+	buf = append(buf, '<')
+	buf = strconv.AppendInt(buf, int64(int(s.facility)<<3|severity), 10)
+	buf = append(buf, '>')
+	t := s.now()
+
+	if s.format == RFC3164 {
+		buf = t.AppendFormat(buf, time.Stamp)
+		buf = append(buf, ' ')
+		// 本地守护进程会自行补充主机名
+		if !s.local && s.hostname != "" {
+			buf = append(buf, s.hostname...)
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, s.appName...)
+		if s.procID != "" {
+			buf = append(buf, '[')
+			buf = append(buf, s.procID...)
+			buf = append(buf, ']')
+		}
+		buf = append(buf, ": "...)
+		return append(buf, msg...)
+	}
+
+	buf = append(buf, "1 "...)
+	buf = t.AppendFormat(buf, "2006-01-02T15:04:05.000000Z07:00")
+	buf = append(buf, ' ')
+	buf = appendHeaderField(buf, s.hostname, 255)
+	buf = append(buf, ' ')
+	buf = appendHeaderField(buf, s.appName, 48)
+	buf = append(buf, ' ')
+	buf = appendHeaderField(buf, s.procID, 128)
+	buf = append(buf, ' ')
+	buf = appendHeaderField(buf, s.msgID, 32)
+	buf = append(buf, ' ')
+	buf = s.appendStructuredData(buf, fields)
+	if len(msg) > 0 {
+		buf = append(buf, ' ')
+		if !isASCII(msg) && utf8.Valid(msg) {
+			buf = append(buf, "\xef\xbb\xbf"...)
+		}
+		buf = append(buf, msg...)
+	}
+	return buf
+}
+
+// appendStructuredData 将字段追加为一个SD-ELEMENT，没有字段时追加NILVALUE
+func (s *SyslogWriter) appendStructuredData(buf []byte, fields []Field) []byte {
+	if len(fields) == 0 {
+		return append(buf, '-')
+	}
+	buf = append(buf, '[')
+	buf = appendSDName(buf, s.sdID, false)
+	for _, f := range fields {
+		buf = append(buf, ' ')
+		buf = appendSDName(buf, f.Key, true)
+		buf = append(buf, `="`...)
+		var v string
+		switch x := f.Value.(type) {
+		case string:
+			v = x
+		case error:
+			v = x.Error()
+		default:
+			v = fmt.Sprint(x)
+		}
+		for i := 0; i < len(v); i++ {
+			switch c := v[i]; c {
+			case '"', '\\', ']':
+				buf = append(buf, '\\', c)
+			default:
+				buf = append(buf, c)
+			}
+		}
+		buf = append(buf, '"')
+	}
+	return append(buf, ']')
+}
+
+// appendHeaderField 追加RFC 5424的头部字段，只保留可打印的ASCII字符并截断到max字节，为空时追加NILVALUE
+func appendHeaderField(buf []byte, s string, max int) []byte {
+	n := 0
+	for i := 0; i < len(s) && n < max; i++ {
+		if c := s[i]; c > ' ' && c < 0x7f {
+			buf = append(buf, c)
+			n++
+		}
+	}
+	if n == 0 {
+		buf = append(buf, '-')
+	}
+	return buf
+}
+
+// appendSDName 追加SD-ID或PARAM-NAME，不允许的字符替换为下划线，最长32个字符
+// SD-ID中的@用于分隔企业编号，PARAM-NAME中不允许出现
+func appendSDName(buf []byte, s string, param bool) []byte {
+	if s == "" {
+		return append(buf, '_')
+	}
+	for i := 0; i < len(s) && i < 32; i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x7f || c == '=' || c == ']' || c == '"' || (param && c == '@') {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+func isASCII(p []byte) bool {
+	for _, c := range p {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// ConcurrentSafe implements ConcurrentWriter.
+func (s *SyslogWriter) ConcurrentSafe() {}
+
+// Close 关闭连接，之后的写入返回 [os.ErrClosed]
+func (s *SyslogWriter) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
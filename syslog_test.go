@@ -0,0 +1,133 @@
+package log
+
+import (
+	"bufio"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var syslogTime = time.Date(2024, 3, 5, 7, 8, 9, 123456000, time.UTC)
+
+func newTestSyslog(t *testing.T, network, addr string, options ...SyslogOption) *SyslogWriter {
+	t.Helper()
+	options = append([]SyslogOption{WithHostname("host"), WithAppName("app"), WithProcID("42")}, options...)
+	s, err := NewSyslogWriter(network, addr, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.now = func() time.Time { return syslogTime }
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func readPacket(t *testing.T, c net.PacketConn) string {
+	t.Helper()
+	c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 2048)
+	n, _, err := c.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf[:n])
+}
+
+func TestSyslogUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	s := newTestSyslog(t, "udp", pc.LocalAddr().String(), WithFacility(FacilityLocal0), WithMsgID("req"))
+
+	l := New(s, "", 0)
+	l.SetFormatter(NewLogfmt())
+	l.setOption(func(o *Option) { o.ShowFuncName = false })
+	l.Warnw("disk full", "path", `/var/"x"]`, "free", 0)
+	want := `<132>1 2024-03-05T07:08:09.123456Z host app 42 req [fields@32473 path="/var/\"x\"\]" free="0"] level=warn msg="disk full" path="/var/\"x\"]" free=0`
+	if got := readPacket(t, pc); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+
+	s.WriteLevel(debugLevel, []byte("héllo\n"))
+	if got := readPacket(t, pc); got != "<135>1 2024-03-05T07:08:09.123456Z host app 42 req - \xef\xbb\xbfhéllo" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSyslogTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	conns := make(chan net.Conn, 2)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns <- c
+		}
+	}()
+	readFrame := func(r *bufio.Reader) string {
+		size, err := r.ReadString(' ')
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(size))
+		buf := make([]byte, n)
+		if _, err := r.Read(buf); err != nil {
+			t.Fatal(err)
+		}
+		return string(buf)
+	}
+
+	s := newTestSyslog(t, "tcp", ln.Addr().String(), WithSyslogFormat(RFC3164))
+	c1 := <-conns
+	defer c1.Close()
+	s.WriteLevel(errorLevel, []byte("first\n"))
+	if got := readFrame(bufio.NewReader(c1)); got != "<11>Mar  5 07:08:09 host app[42]: first" {
+		t.Errorf("got %q", got)
+	}
+
+	// 连接断开后重新连接
+	s.mu.Lock()
+	s.conn.Close()
+	s.mu.Unlock()
+	if _, err := s.WriteLevel(fatalLevel, []byte("second")); err != nil {
+		t.Fatal(err)
+	}
+	c2 := <-conns
+	defer c2.Close()
+	if got := readFrame(bufio.NewReader(c2)); got != "<9>Mar  5 07:08:09 host app[42]: second" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSyslogLocal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	pc, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer pc.Close()
+	old := syslogSockets
+	syslogSockets = []string{filepath.Join(t.TempDir(), "missing"), path}
+	defer func() { syslogSockets = old }()
+
+	s := newTestSyslog(t, "", "")
+	s.Write([]byte("hello"))
+	// 本地默认使用RFC 3164，由守护进程补充主机名
+	if got := readPacket(t, pc); got != "<14>Mar  5 07:08:09 app[42]: hello" {
+		t.Errorf("got %q", got)
+	}
+	s.Close()
+	if _, err := s.Write([]byte("x")); err == nil {
+		t.Error("write after Close succeeded")
+	}
+}
@@ -0,0 +1,129 @@
+package log
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// moduleRule 一条vmodule规则
+type moduleRule struct {
+	pattern string
+	level   int32
+}
+
+// vmodule 按调用位置覆盖日志等级的规则，规则修改时整体替换，缓存随之失效
+type vmodule struct {
+	spec  string
+	rules []moduleRule
+	cache sync.Map // pc -> int32，-1表示没有匹配的规则
+}
+
+// parseModuleSpec 解析形如 "net/*=debug,db=warn" 的规则
+func parseModuleSpec(spec string) (*vmodule, error) {
+	v := &vmodule{spec: spec}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pattern, level, ok := strings.Cut(item, "=")
+		pattern, level = strings.TrimSpace(pattern), strings.TrimSpace(level)
+		if !ok || pattern == "" || level == "" {
+			return nil, fmt.Errorf("log: invalid vmodule rule %q", item)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("log: invalid vmodule pattern %q: %w", pattern, err)
+		}
+		lv, err := ParseLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("log: invalid vmodule rule %q: %w", item, err)
+		}
+		v.rules = append(v.rules, moduleRule{pattern: strings.TrimSuffix(pattern, ".go"), level: int32(lv)})
+	}
+	return v, nil
+}
+
+// level 返回调用位置pc上生效的规则等级，没有匹配的规则时返回-1
+func (v *vmodule) level(pc uintptr) int32 {
+	if lv, ok := v.cache.Load(pc); ok {
+		return lv.(int32)
+	}
+	lv := int32(-1)
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	file := strings.TrimSuffix(frame.File, ".go")
+	pkg := funcPackage(frame.Function)
+	for _, r := range v.rules {
+		if matchSuffix(r.pattern, file) || matchSuffix(r.pattern, pkg) {
+			lv = r.level
+			break
+		}
+	}
+	v.cache.Store(pc, lv)
+	return lv
+}
+
+// funcPackage 从函数全名中取出包路径，例如 github.com/a/b.(*T).M 返回 github.com/a/b
+func funcPackage(fn string) string {
+	slash := strings.LastIndexByte(fn, '/') + 1
+	if dot := strings.IndexByte(fn[slash:], '.'); dot >= 0 {
+		return fn[:slash+dot]
+	}
+	return fn
+}
+
+// matchSuffix 用pattern匹配name末尾与之层级数相同的部分
+// 例如 "db" 匹配 "app/db"，"net/*" 匹配 "golang.org/x/net/http"
+func matchSuffix(pattern, name string) bool {
+	if name == "" {
+		return false
+	}
+	n := strings.Count(pattern, "/")
+	i := len(name)
+	for ; n >= 0 && i > 0; n-- {
+		i = strings.LastIndexByte(name[:i], '/')
+		if i < 0 {
+			break
+		}
+	}
+	ok, _ := path.Match(pattern, name[i+1:])
+	return ok
+}
+
+// SetModuleLevel 按调用者的文件或包路径覆盖日志等级，例如 "net/*=debug,db=warn"
+// 规则按顺序匹配，第一条匹配的规则生效；没有层级的模式匹配文件名（不含.go）或包名，
+// 带有层级的模式匹配文件或包路径末尾相同层级数的部分。空字符串清除所有规则
+func (l *Log) SetModuleLevel(spec string) error {
+	v, err := parseModuleSpec(spec)
+	if err != nil {
+		return err
+	}
+	if len(v.rules) == 0 {
+		v = nil
+	}
+	l.vmodule.Store(v)
+	return nil
+}
+
+// ModuleLevel 返回当前的vmodule规则
+func (l *Log) ModuleLevel() string {
+	if v := l.vmodule.Load(); v != nil {
+		return v.spec
+	}
+	return ""
+}
+
+// enabled 判断调用位置pc上的日志是否需要输出
+func (l *Log) enabled(v *vmodule, pc uintptr, level int32) bool {
+	if lv := v.level(pc); lv >= 0 {
+		return level >= lv
+	}
+	return level >= l.lv.level.Load()
+}
+
+// SetModuleLevel 设置默认日志实例的vmodule规则
+func SetModuleLevel(spec string) error {
+	return std.SetModuleLevel(spec)
+}
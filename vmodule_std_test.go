@@ -0,0 +1,62 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/nbcx/log"
+)
+
+// 包级函数经由std调用，规则需要匹配调用包级函数的包，而不是log包本身
+func TestPackageLevelModuleLevel(t *testing.T) {
+	var buf bytes.Buffer
+	std := log.Default()
+	std.SetWriter(&buf)
+	std.SetLevel(log.LevelInfo)
+	defer func() {
+		std.SetWriter(os.Stdout)
+		std.SetLevel(log.LevelDebug)
+		log.SetModuleLevel("")
+	}()
+
+	log.Debug("filtered")
+	if err := log.SetModuleLevel("log_test=debug"); err != nil {
+		t.Fatal(err)
+	}
+	log.Debug("debug from log_test")
+	log.Debugw("debugw from log_test")
+	log.DebugContext(context.Background(), "debug context from log_test")
+	if err := log.SetModuleLevel("log=debug"); err != nil {
+		t.Fatal(err)
+	}
+	log.Debug("matched the library instead of the caller")
+
+	out := buf.String()
+	if strings.Contains(out, "filtered") || strings.Contains(out, "instead") ||
+		!strings.Contains(out, "debug from log_test") || !strings.Contains(out, "debugw from log_test") || !strings.Contains(out, "debug context from log_test") {
+		t.Errorf("got %q", out)
+	}
+}
+
+// 速率采样按用户代码的调用位置区分，而不是包级函数所在的位置
+func TestPackageLevelRateSampler(t *testing.T) {
+	var buf bytes.Buffer
+	std := log.Default()
+	std.SetWriter(&buf)
+	std.SetSampler(log.NewRateSampler(0, 1))
+	defer func() {
+		std.SetWriter(os.Stdout)
+		std.SetSampler(nil)
+	}()
+
+	for i := 0; i < 3; i++ {
+		log.Info("first site")
+		log.Info("second site")
+	}
+	if out := buf.String(); strings.Count(out, "first site") != 1 || strings.Count(out, "second site") != 1 {
+		t.Errorf("got %q", out)
+	}
+}
@@ -0,0 +1,62 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSetModuleLevel(t *testing.T) {
+	var b bytes.Buffer
+	l := New(&b, "", 0)
+	l.SetFormatter(&DefaultFormat{})
+	l.SetLevel(errorLevel)
+
+	for _, tt := range []struct {
+		spec string
+		want string
+	}{
+		{"", ""},
+		{"vmodule_test=debug", "[debug] x\n"},
+		{"vmodule_test.go=info", ""},
+		{"other=debug,nbcx/log=debug", "[debug] x\n"},
+		{"github.com/*/log=debug", "[debug] x\n"},
+		{"net/*=debug", ""},
+		{"log=warn,vmodule_test=debug", ""},
+	} {
+		b.Reset()
+		if err := l.SetModuleLevel(tt.spec); err != nil {
+			t.Fatal(err)
+		}
+		l.Debug("x")
+		if got := b.String(); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.spec, got, tt.want)
+		}
+	}
+	if got := l.ModuleLevel(); got != "log=warn,vmodule_test=debug" {
+		t.Errorf("ModuleLevel() = %q", got)
+	}
+
+	for _, spec := range []string{"db", "=debug", "[=debug"} {
+		if err := l.SetModuleLevel(spec); err == nil {
+			t.Errorf("SetModuleLevel(%q) should fail", spec)
+		}
+	}
+}
+
+func TestMatchSuffix(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"db", "github.com/me/app/db", true},
+		{"db", "github.com/me/app/db/pool", false},
+		{"net/*", "golang.org/x/net/http", true},
+		{"net/*", "net/http", true},
+		{"net/*", "http", false},
+		{"*", "main", true},
+	} {
+		if got := matchSuffix(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchSuffix(%q, %q) = %v", tt.pattern, tt.name, got)
+		}
+	}
+}
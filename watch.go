@@ -0,0 +1,142 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"time"
+)
+
+// configWatcher 定期检查配置文件，内容变化时重新加载
+type configWatcher struct {
+	path     string
+	l        *Log
+	interval time.Duration
+
+	data  []byte                   // 最近一次读取的内容，无论是否加载成功
+	files map[string]*RotatingFile // 当前配置打开的文件
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// WatchOption 配置文件监听的配置
+type WatchOption func(w *configWatcher)
+
+// WithPollInterval 设置检查配置文件的间隔，默认为1秒
+func WithPollInterval(d time.Duration) WatchOption {
+	return func(w *configWatcher) {
+		w.interval = d
+	}
+}
+
+// WatchConfig 加载配置文件并作用于l，之后定期检查文件，内容变化时重新加载
+// 配置文件的格式见 [LoadConfig]，重新加载时应用等级、flag、前缀、vmodule、格式器和输出，caller_depth只在首次加载时生效
+// 输出整体替换，不会丢失或重复日志；路径不变的文件继续使用并应用新的切割配置，不再使用的文件会被关闭，
+// l原有的、不是由配置文件打开的输出不会被关闭
+// 重新加载失败时通过l输出一条error日志并保留之前的配置；首次加载失败时直接返回错误
+// 调用stop停止监听，已打开的文件由 [Log.Close] 关闭
+func WatchConfig(path string, l *Log, options ...WatchOption) (stop func(), err error) {
+	w := &configWatcher{
+		path:     path,
+		l:        l,
+		interval: time.Second,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, op := range options {
+		op(w)
+	}
+	if w.data, err = os.ReadFile(path); err != nil {
+		return nil, err
+	}
+	c, err := decodeConfig(path, w.data)
+	if err != nil {
+		return nil, err
+	}
+	st, err := c.prepare(nil)
+	if err != nil {
+		return nil, err
+	}
+	l.setOption(func(o *Option) { o.CallDepth = st.callDepth })
+	w.apply(st)
+
+	go w.run()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(w.stop)
+			<-w.done
+		})
+	}, nil
+}
+
+func (w *configWatcher) run() {
+	defer close(w.done)
+	t := time.NewTicker(w.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-t.C:
+			w.reload()
+		}
+	}
+}
+
+// reload 文件内容变化时重新加载，失败的内容不会重复汇报
+func (w *configWatcher) reload() {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		if w.data != nil {
+			w.l.Error("log: reload config %s: %v, keeping previous configuration", w.path, err)
+			w.data = nil
+		}
+		return
+	}
+	if bytes.Equal(data, w.data) {
+		return
+	}
+	w.data = data
+	c, err := decodeConfig(w.path, data)
+	if err == nil {
+		var st *configState
+		if st, err = c.prepare(w.files); err == nil {
+			w.apply(st)
+			return
+		}
+	}
+	w.l.Error("log: reload config %s: %v, keeping previous configuration", w.path, err)
+}
+
+// apply 应用配置并关闭不再使用的文件
+func (w *configWatcher) apply(st *configState) {
+	st.apply(w.l)
+	old := w.files
+	w.files = st.outs.files
+
+	var removed []*RotatingFile
+	for path, f := range old {
+		if w.files[path] != f {
+			removed = append(removed, f)
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+	// apply返回后旧的输出已经没有正在进行的写入，汇报被合并的重复日志后即可关闭
+	w.l.mu.Lock()
+	if d := w.l.dedup.Load(); d != nil {
+		for _, f := range removed {
+			if ds := d.last[f]; ds != nil {
+				d.report(w.l.core, f, ds)
+				delete(d.last, f)
+			}
+		}
+	}
+	w.l.mu.Unlock()
+	for _, f := range removed {
+		_ = f.Close()
+	}
+}
@@ -0,0 +1,210 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor 等待cond成立，超时后测试失败
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "log.yaml")
+	a, b := filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")
+	write := func(s string) {
+		// 写入临时文件后改名，避免读到写了一半的内容
+		tmp := cfg + ".tmp"
+		os.WriteFile(tmp, []byte(s), 0o644)
+		os.Rename(tmp, cfg)
+	}
+	read := func(path string) string {
+		b, _ := os.ReadFile(path)
+		return string(b)
+	}
+
+	write("level: info\nformatter: logfmt\nflags: none\noutputs:\n  - path: " + a + "\n")
+	l := New(discard{}, "", 0)
+	stop, err := WatchConfig(cfg, l, WithPollInterval(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	l.Debug("hidden")
+	l.Info("first")
+	write("level: warn\nformatter: json\nflags: none\noutputs:\n  - path: " + b + "\n")
+	waitFor(t, func() bool { return l.Level() == LevelWarn })
+	l.Warn("second")
+
+	if got := read(a); got != "level=info msg=first\n" {
+		t.Errorf("a.log = %q", got)
+	}
+	if got := read(b); got != `{"level":"warn","msg":"second","func":"github.com/nbcx/log.TestWatchConfig"}`+"\n" {
+		t.Errorf("b.log = %q", got)
+	}
+
+	// 无效的配置被汇报并保留之前的配置
+	write("level: eror\noutputs:\n  - path: " + a + "\n")
+	waitFor(t, func() bool { return strings.Contains(read(b), "reload config") })
+	if !strings.Contains(read(b), `unknown level \"eror\"`) || l.Level() != LevelWarn {
+		t.Errorf("b.log = %q, level %v", read(b), l.Level())
+	}
+	stop()
+	l.Close()
+}
+
+func TestWatchConfigInitialError(t *testing.T) {
+	cfg := filepath.Join(t.TempDir(), "log.json")
+	os.WriteFile(cfg, []byte(`{"level":"loud"}`), 0o644)
+	if _, err := WatchConfig(cfg, New(discard{}, "", 0)); err == nil {
+		t.Error("WatchConfig accepted invalid config")
+	}
+	if _, err := WatchConfig(cfg+".missing", New(discard{}, "", 0)); err == nil {
+		t.Error("WatchConfig accepted missing file")
+	}
+}
+
+func TestWatchConfigConcurrentReload(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")
+	configs := []string{
+		`{"formatter":"json","flags":"none","outputs":[{"path":"` + a + `"}]}`,
+		`{"formatter":"logfmt","flags":"shortfile","prefix":"db","outputs":[{"path":"` + b + `"}]}`,
+	}
+	l := New(discard{}, "", 0)
+	w := &configWatcher{l: l}
+	reload := func(i int) {
+		c, err := decodeConfig("log.json", []byte(configs[i%2]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		st, err := c.prepare(w.files)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.apply(st)
+	}
+	reload(0)
+
+	// 重新加载的同时持续输出日志
+	var logged atomic.Int64
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				l.Info("hello")
+				logged.Add(1)
+			}
+		}()
+	}
+	for i := 1; i < 20; i++ {
+		reload(i)
+		time.Sleep(time.Millisecond)
+	}
+	close(stop)
+	wg.Wait()
+	l.Close()
+
+	// 每条日志都只使用一份配置，并且没有因为文件被关闭而丢失
+	var n int
+	for path, want := range map[string]string{
+		a: `{"level":"info","msg":"hello"`,
+		b: "level=info logger=db msg=hello caller=watch_test.go:",
+	} {
+		data, _ := os.ReadFile(path)
+		for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+			if line == "" {
+				continue
+			}
+			if !strings.HasPrefix(line, want) {
+				t.Errorf("%s: %q", filepath.Base(path), line)
+			}
+			n++
+		}
+	}
+	if int64(n) != logged.Load() {
+		t.Errorf("got %d lines, want %d", n, logged.Load())
+	}
+}
+
+func TestWatchConfigChildren(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "log.json")
+	out := filepath.Join(dir, "out.log")
+	os.WriteFile(cfg, []byte(`{"formatter":"logfmt","flags":"none","prefix":"a","outputs":[{"path":"`+out+`"}]}`), 0o644)
+	l := New(discard{}, "", 0)
+	w := &configWatcher{l: l}
+	c, _ := LoadConfig(cfg)
+	st, err := c.prepare(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.apply(st)
+	db, kv := l.Named("db"), l.With("k", 1)
+
+	// 重新加载后，已经创建的子实例也使用新的flag，With子实例使用新的前缀
+	c = &Config{Formatter: "logfmt", Flags: "shortfile", Prefix: "b", Outputs: []OutputConfig{{Path: out}}}
+	if st, err = c.prepare(w.files); err != nil {
+		t.Fatal(err)
+	}
+	w.apply(st)
+	db.Info("x")
+	kv.Info("y")
+	l.Close()
+
+	b, _ := os.ReadFile(out)
+	pattern := `^level=info logger=db msg=x caller=watch_test\.go:[0-9]+\nlevel=info logger=b msg=y caller=watch_test\.go:[0-9]+ k=1\n$`
+	if !regexp.MustCompile(pattern).Match(b) {
+		t.Errorf("output %q does not match %q", b, pattern)
+	}
+}
+
+func TestWatchConfigRotateOptions(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.log")
+	l := New(discard{}, "", 0)
+	w := &configWatcher{l: l}
+	for _, c := range []*Config{
+		{Formatter: "logfmt", Flags: "none", Outputs: []OutputConfig{{Path: out}}},
+		{Formatter: "logfmt", Flags: "none", Outputs: []OutputConfig{{Path: out, MaxSize: 10, MaxBackups: 1}}},
+	} {
+		st, err := c.prepare(w.files)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.apply(st)
+	}
+	f := w.files[out]
+	// 路径不变的文件使用新的切割配置
+	for i := 0; i < 3; i++ {
+		l.Info("line")
+	}
+	l.Close()
+	if backups, _ := f.backups(); len(backups) != 1 {
+		t.Errorf("got %d backups, want 1", len(backups))
+	}
+}